@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"k8s.io/klog/v2"
+)
+
+// Record is an audit record of an Azure mutation issued by the driver
+type Record struct {
+	Time          time.Time         `json:"time"`
+	Operation     string            `json:"operation"`
+	CorrelationID string            `json:"correlationID"`
+	Succeeded     bool              `json:"succeeded"`
+	DurationMs    int64             `json:"durationMs"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+}
+
+// Logger writes audit records as JSON lines. A nil Logger discards all records.
+type Logger struct {
+	lock sync.Mutex
+	out  io.Writer
+}
+
+// NewLogger returns a Logger appending to the file at path
+func NewLogger(path string) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{out: f}, nil
+}
+
+// Log writes record to the audit log
+func (l *Logger) Log(record Record) {
+	if l == nil {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		klog.Errorf("failed to marshal audit record of %s(%s): %v", record.Operation, record.CorrelationID, err)
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		klog.Errorf("failed to write audit record of %s(%s): %v", record.Operation, record.CorrelationID, err)
+	}
+}
+
+// Operation is an audited operation in progress
+type Operation struct {
+	logger        *Logger
+	name          string
+	correlationID string
+	start         time.Time
+}
+
+// StartOperation starts auditing the operation name. It returns nil if l is nil.
+func (l *Logger) StartOperation(name string) *Operation {
+	if l == nil {
+		return nil
+	}
+	op := &Operation{
+		logger:        l,
+		name:          name,
+		correlationID: uuid.NewUUID().String(),
+		start:         time.Now(),
+	}
+	klog.V(4).Infof("audit: %s started with correlationID(%s)", name, op.correlationID)
+	return op
+}
+
+// Finish logs the outcome of the operation with the attributes given as key/value pairs
+func (op *Operation) Finish(succeeded bool, keysAndValues ...string) {
+	if op == nil {
+		return
+	}
+	attributes := make(map[string]string, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i+1] != "" {
+			attributes[keysAndValues[i]] = keysAndValues[i+1]
+		}
+	}
+	op.logger.Log(Record{
+		Time:          op.start,
+		Operation:     op.name,
+		CorrelationID: op.correlationID,
+		Succeeded:     succeeded,
+		DurationMs:    time.Since(op.start).Milliseconds(),
+		Attributes:    attributes,
+	})
+}
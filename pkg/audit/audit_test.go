@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(path)
+	require.NoError(t, err)
+
+	logger.StartOperation("controller_create_volume").Finish(true, "volumeid", "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/disk", "node", "")
+	logger.StartOperation("controller_delete_volume").Finish(false, "volumeid")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "controller_create_volume", records[0].Operation)
+	assert.True(t, records[0].Succeeded)
+	assert.NotEmpty(t, records[0].CorrelationID)
+	assert.Equal(t, map[string]string{"volumeid": "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/disk"}, records[0].Attributes)
+
+	assert.Equal(t, "controller_delete_volume", records[1].Operation)
+	assert.False(t, records[1].Succeeded)
+	assert.Empty(t, records[1].Attributes)
+	assert.NotEqual(t, records[0].CorrelationID, records[1].CorrelationID)
+}
+
+func TestNilLogger(t *testing.T) {
+	var logger *Logger
+	op := logger.StartOperation("controller_create_volume")
+	assert.Nil(t, op)
+	op.Finish(true)
+	logger.Log(Record{})
+}
+
+func TestNewLoggerFailure(t *testing.T) {
+	_, err := NewLogger(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.Error(t, err)
+}
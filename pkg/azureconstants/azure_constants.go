@@ -33,14 +33,19 @@ const (
 	DiskIOPSReadWriteField        = "diskiopsreadwrite"
 	DiskMBPSReadWriteField        = "diskmbpsreadwrite"
 	DiskNameField                 = "diskname"
+	DiskNameTemplateField         = "disknametemplate"
 	EnableBurstingField           = "enablebursting"
+	EncryptedField                = "encrypted"
 	ErrDiskNotFound               = "not found"
+	FsParamsField                 = "fsparams"
 	FsTypeField                   = "fstype"
 	IncrementalField              = "incremental"
 	KindField                     = "kind"
 	LocationField                 = "location"
 	LogicalSectorSizeField        = "logicalsectorsize"
 	LUN                           = "LUN"
+	LuksEncryption                = "luks"
+	LuksPassphraseKey             = "passphrase"
 	MaxSharesField                = "maxshares"
 	MinimumDiskSizeGiB            = 1
 	NetworkAccessPolicyField      = "networkaccesspolicy"
@@ -48,13 +53,16 @@ const (
 	PerfProfileBasic              = "basic"
 	PerfProfileField              = "perfprofile"
 	PerfProfileNone               = "none"
+	PerfProfileNVMe               = "nvme"
 	PremiumAccountPrefix          = "premium"
+	PublicNetworkAccessField      = "publicnetworkaccess"
 	PvcNameKey                    = "csi.storage.k8s.io/pvc/name"
 	PvcNamespaceKey               = "csi.storage.k8s.io/pvc/namespace"
 	PvcNamespaceTag               = "kubernetes.io-created-for-pvc-namespace"
 	PvcNameTag                    = "kubernetes.io-created-for-pvc-name"
 	PvNameKey                     = "csi.storage.k8s.io/pv/name"
 	PvNameTag                     = "kubernetes.io-created-for-pv-name"
+	PvcAnnotationParameterPrefix  = "disk.csi.azure.com/"
 	RateLimited                   = "rate limited"
 	RequestedSizeGib              = "requestedsizegib"
 	ResizeRequired                = "resizeRequired"
@@ -68,6 +76,8 @@ const (
 	StandardSsdAccountPrefix      = "standardssd"
 	StorageAccountTypeField       = "storageaccounttype"
 	TagsField                     = "tags"
+	TagsFromLabelsField           = "tagsfromlabels"
+	TierField                     = "tier"
 	ThrottlingKey                 = "throttlingKey"
 	TrueValue                     = "true"
 	FalseValue                    = "false"
@@ -91,6 +101,7 @@ const (
 
 var (
 	// ManagedDiskPath is described here: https://docs.microsoft.com/en-us/rest/api/compute/disks/createorupdate#create-a-managed-disk-from-an-existing-managed-disk-in-the-same-or-different-subscription.
-	ManagedDiskPath   = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/%s"
-	ManagedDiskPathRE = regexp.MustCompile(`(?i).*/subscriptions/(?:.*)/resourceGroups/(?:.*)/providers/Microsoft.Compute/disks/(.+)`)
+	ManagedDiskPath     = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/%s"
+	ManagedDiskPathRE   = regexp.MustCompile(`(?i).*/subscriptions/(?:.*)/resourceGroups/(?:.*)/providers/Microsoft.Compute/disks/(.+)`)
+	ManagedSnapshotPath = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/snapshots/%s"
 )
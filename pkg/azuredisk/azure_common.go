@@ -17,12 +17,69 @@ limitations under the License.
 package azuredisk
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	mount "k8s.io/mount-utils"
 )
 
+// resizeVolumeBackoff is the backoff used to retry filesystem expansion that failed transiently
+var resizeVolumeBackoff = wait.Backoff{
+	Duration: 1 * time.Second,
+	Factor:   2.0,
+	Steps:    5,
+}
+
 func strFirstLetterToUpper(str string) string {
 	if len(str) < 2 {
 		return str
 	}
 	return strings.ToUpper(string(str[0])) + str[1:]
 }
+
+// resizeVolumeWithRetry resizes the filesystem on devicePath, retrying with backoff while the device is busy
+func resizeVolumeWithRetry(ctx context.Context, devicePath, volumePath string, m *mount.SafeFormatAndMount) error {
+	return retryTransientResize(ctx, resizeVolumeBackoff, func() error {
+		if err := resizeVolume(devicePath, volumePath, m); err != nil {
+			if isTransientResizeError(err) {
+				klog.Warningf("resize volume %s (%s) failed transiently, will retry: %v", devicePath, volumePath, err)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// retryTransientResize calls resize until it succeeds, fails with a non-transient error, the backoff
+// is exhausted or ctx is done. The last resize error is returned if resize never succeeded.
+func retryTransientResize(ctx context.Context, backoff wait.Backoff, resize func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, backoff, func() (bool, error) {
+		if lastErr = resize(); lastErr != nil {
+			if isTransientResizeError(lastErr) {
+				return false, nil
+			}
+			return false, lastErr
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	if err != nil && lastErr != nil && err != lastErr {
+		return fmt.Errorf("%v, last resize error: %v", err, lastErr)
+	}
+	return err
+}
+
+func isTransientResizeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "device or resource busy") || strings.Contains(msg, "resource temporarily unavailable")
+}
@@ -38,7 +38,7 @@ const sysClassBlockPath = "/sys/class/block/"
 func scsiHostRescan(io azureutils.IOHandler, m *mount.SafeFormatAndMount) {
 }
 
-func formatAndMount(source, target, fstype string, options []string, m *mount.SafeFormatAndMount) error {
+func formatAndMount(source, target, fstype string, options, formatOptions []string, m *mount.SafeFormatAndMount) error {
 	return nil
 }
 
@@ -122,3 +122,19 @@ func rescanAllVolumes(io azureutils.IOHandler) error {
 func GetVolumeStats(ctx context.Context, m *mount.SafeFormatAndMount, target string, hostutil hostUtil) ([]*csi.VolumeUsage, error) {
 	return []*csi.VolumeUsage{}, nil
 }
+
+func openLuksDevice(source, diskURI, passphrase string, m *mount.SafeFormatAndMount) (string, error) {
+	return "", fmt.Errorf("LUKS encryption is not supported on darwin")
+}
+
+func closeLuksDevice(diskURI string, m *mount.SafeFormatAndMount) error {
+	return nil
+}
+
+func resizeLuksDevice(mapperName string, rescan bool, io azureutils.IOHandler, m *mount.SafeFormatAndMount) error {
+	return fmt.Errorf("LUKS encryption is not supported on darwin")
+}
+
+func getVolumeCondition(target string, io azureutils.IOHandler) *csi.VolumeCondition {
+	return nil
+}
@@ -34,9 +34,22 @@ import (
 	"k8s.io/kubernetes/pkg/volume"
 	mount "k8s.io/mount-utils"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/crypt"
 )
 
-const sysClassBlockPath = "/sys/class/block/"
+// procMountInfoPath is a variable so that it can be replaced in unit tests
+var procMountInfoPath = "/proc/self/mountinfo"
+
+const (
+	sysClassBlockPath = "/sys/class/block/"
+	sysClassNVMePath  = "/sys/class/nvme/"
+	// azureNVMeDataDiskByLunPath is populated by the azure-vm-utils udev rules on VMs with an NVMe disk controller
+	azureNVMeDataDiskByLunPath = "/dev/disk/azure/data/by-lun/"
+	// azureNVMeRemoteDiskModel is the model of the NVMe controller exposing remote (managed) disks
+	azureNVMeRemoteDiskModel = "MSFT NVMe Accelerator"
+	// namespace 1 is the OS disk and data disk LUNs start from namespace 2
+	azureNVMeDataDiskNamespaceOffset = 2
+)
 
 // exclude those used by azure as resource and OS root in /dev/disk/azure, /dev/disk/azure/scsi0
 // "/dev/disk/azure/scsi0" dir is populated in Standard_DC4s/DC2s on Ubuntu 18.04
@@ -98,13 +111,167 @@ func scsiHostRescan(io azureutils.IOHandler, m *mount.SafeFormatAndMount) {
 
 func findDiskByLun(lun int, io azureutils.IOHandler, m *mount.SafeFormatAndMount) (string, error) {
 	azureDisks := listAzureDiskPath(io)
-	return findDiskByLunWithConstraint(lun, io, azureDisks)
+	device, err := findDiskByLunWithConstraint(lun, io, azureDisks)
+	if device == "" && err == nil {
+		// VM sizes with an NVMe disk controller expose data disks as NVMe namespaces instead of SCSI LUNs
+		device, err = findNVMeDiskByLun(lun, io)
+	}
+	return device, err
 }
 
-func formatAndMount(source, target, fstype string, options []string, m *mount.SafeFormatAndMount) error {
+// findNVMeDiskByLun finds the NVMe namespace of the remote data disk attached at lun
+func findNVMeDiskByLun(lun int, io azureutils.IOHandler) (string, error) {
+	diskPath := filepath.Join(azureNVMeDataDiskByLunPath, strconv.Itoa(lun))
+	if _, err := io.Readlink(diskPath); err == nil {
+		klog.V(4).Infof("azureDisk - found %s for lun %d", diskPath, lun)
+		return diskPath, nil
+	}
+
+	controllers, err := io.ReadDir(sysClassNVMePath)
+	if err != nil {
+		klog.V(4).Infof("azureDisk - failed to read %s, err %v", sysClassNVMePath, err)
+		return "", nil
+	}
+	for _, c := range controllers {
+		controller := c.Name()
+		modelBytes, err := io.ReadFile(filepath.Join(sysClassNVMePath, controller, "model"))
+		if err != nil {
+			klog.V(4).Infof("failed to read model of NVMe controller %s, err: %v", controller, err)
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(string(modelBytes)), azureNVMeRemoteDiskModel) {
+			continue
+		}
+
+		namespaces, err := io.ReadDir(filepath.Join(sysClassNVMePath, controller))
+		if err != nil {
+			klog.Errorf("failed to list namespaces of NVMe controller %s, err: %v", controller, err)
+			continue
+		}
+		for _, ns := range namespaces {
+			// look for namespaces like nvme0n2 under /sys/class/nvme/nvme0
+			namespace := ns.Name()
+			if !strings.HasPrefix(namespace, controller+"n") {
+				continue
+			}
+			nsidBytes, err := io.ReadFile(filepath.Join(sysClassNVMePath, controller, namespace, "nsid"))
+			if err != nil {
+				klog.V(4).Infof("failed to read nsid of NVMe namespace %s, err: %v", namespace, err)
+				continue
+			}
+			nsid, err := strconv.Atoi(strings.TrimSpace(string(nsidBytes)))
+			if err != nil {
+				klog.V(4).Infof("failed to parse nsid of NVMe namespace %s, err: %v", namespace, err)
+				continue
+			}
+			if nsid-azureNVMeDataDiskNamespaceOffset == lun {
+				return "/dev/" + namespace, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func formatAndMount(source, target, fstype string, options, formatOptions []string, m *mount.SafeFormatAndMount) error {
+	if len(formatOptions) > 0 {
+		if err := formatWithOptions(source, fstype, options, formatOptions, m); err != nil {
+			return err
+		}
+	}
 	return m.FormatAndMount(source, target, fstype, options)
 }
 
+// openLuksDevice opens the LUKS device on source for the disk diskURI and returns its path.
+// An unformatted source is formatted as LUKS2 first, a source formatted with a filesystem is never overwritten.
+func openLuksDevice(source, diskURI, passphrase string, m *mount.SafeFormatAndMount) (string, error) {
+	mapperPath := crypt.MapperPath(diskURI)
+	if _, err := os.Stat(mapperPath); err == nil {
+		klog.V(2).Infof("LUKS device %s is already open", mapperPath)
+		return mapperPath, nil
+	}
+
+	existingFormat, err := m.GetDiskFormat(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to get disk format of disk %s: %v", source, err)
+	}
+
+	c := crypt.New(m.Exec)
+	switch existingFormat {
+	case "":
+		if err := c.Format(source, passphrase); err != nil {
+			return "", err
+		}
+	case crypt.LuksFormat:
+	default:
+		return "", fmt.Errorf("disk %s is already formatted as %s, LUKS encryption can only be set up on an unformatted disk", source, existingFormat)
+	}
+
+	if err := c.Open(source, crypt.MapperName(diskURI), passphrase); err != nil {
+		return "", err
+	}
+	return mapperPath, nil
+}
+
+// closeLuksDevice closes the LUKS device opened for the disk diskURI, if any.
+func closeLuksDevice(diskURI string, m *mount.SafeFormatAndMount) error {
+	if _, err := os.Stat(crypt.MapperPath(diskURI)); err != nil {
+		return nil
+	}
+	return crypt.New(m.Exec).Close(crypt.MapperName(diskURI))
+}
+
+// resizeLuksDevice grows the LUKS device mapperName to the size of its backing device, rescanning the backing device first if rescan is set.
+func resizeLuksDevice(mapperName string, rescan bool, io azureutils.IOHandler, m *mount.SafeFormatAndMount) error {
+	c := crypt.New(m.Exec)
+	if rescan {
+		backingDevice, err := c.GetBackingDevice(mapperName)
+		if err != nil {
+			return err
+		}
+		if err := rescanVolume(io, backingDevice); err != nil {
+			klog.Errorf("rescanVolume(%s) failed with error: %v", backingDevice, err)
+		}
+	}
+	return c.Resize(mapperName)
+}
+
+// mkfsDefaultOptions returns the mkfs options SafeFormatAndMount formats a disk of fstype with
+func mkfsDefaultOptions(fstype string) []string {
+	switch fstype {
+	case "ext3", "ext4":
+		return []string{"-F", "-m0"}
+	case "xfs":
+		return []string{"-f"}
+	}
+	return nil
+}
+
+// formatWithOptions formats an unformatted disk with mkfs using formatOptions, which
+// SafeFormatAndMount does not support, after the options SafeFormatAndMount would use.
+// Formatted and read-only disks are left untouched.
+func formatWithOptions(source, fstype string, options, formatOptions []string, m *mount.SafeFormatAndMount) error {
+	for _, option := range options {
+		if option == "ro" {
+			return nil
+		}
+	}
+
+	existingFormat, err := m.GetDiskFormat(source)
+	if err != nil {
+		return fmt.Errorf("failed to get disk format of disk %s: %v", source, err)
+	}
+	if existingFormat != "" {
+		return nil
+	}
+
+	mkfsOptions := append(mkfsDefaultOptions(fstype), formatOptions...)
+	klog.V(2).Infof("formatting disk %s as %s with options %v", source, fstype, mkfsOptions)
+	if output, err := m.Exec.Command("mkfs."+fstype, append(mkfsOptions, source)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("format of disk %s as %s with options %v failed: %v, output: %s", source, fstype, mkfsOptions, err, string(output))
+	}
+	return nil
+}
+
 // finds a device mounted to "current" node
 func findDiskByLunWithConstraint(lun int, io azureutils.IOHandler, azureDisks []string) (string, error) {
 	var err error
@@ -211,7 +378,11 @@ func getDevicePathWithMountPath(mountPath string, m *mount.SafeFormatAndMount) (
 		return "", fmt.Errorf("could not determine device path(%s), error: %v", mountPath, err)
 	}
 
+	// findmnt reports the source of a bind mounted subdirectory as device[/subdir]
 	devicePath := strings.TrimSpace(string(output))
+	if i := strings.Index(devicePath, "["); i > 0 {
+		devicePath = devicePath[:i]
+	}
 	if len(devicePath) == 0 {
 		return "", fmt.Errorf("could not get valid device for mount path: %q", mountPath)
 	}
@@ -269,6 +440,57 @@ func rescanAllVolumes(io azureutils.IOHandler) error {
 	return nil
 }
 
+// getVolumeCondition reports the volume mounted at target as abnormal if its filesystem was remounted read-only,
+// e.g. by ext4 errors=remount-ro after I/O errors, or if its SCSI device or NVMe controller is no longer operational.
+func getVolumeCondition(target string, io azureutils.IOHandler) *csi.VolumeCondition {
+	mountInfos, err := mount.ParseMountInfo(procMountInfoPath)
+	if err != nil {
+		klog.Warningf("failed to parse %s: %v", procMountInfoPath, err)
+		return nil
+	}
+
+	target = filepath.Clean(target)
+	for _, mi := range mountInfos {
+		if mi.MountPoint != target {
+			continue
+		}
+		if hasOption(mi.MountOptions, "rw") && hasOption(mi.SuperOptions, "ro") {
+			return &csi.VolumeCondition{
+				Abnormal: true,
+				Message:  fmt.Sprintf("filesystem on %s was remounted read-only, check the kernel log for I/O errors", mi.Source),
+			}
+		}
+		deviceName := filepath.Base(mi.Source)
+		statePath := filepath.Join(sysClassBlockPath, deviceName, "device/state")
+		if state, err := io.ReadFile(statePath); err == nil && !isDeviceStateHealthy(deviceName, strings.TrimSpace(string(state))) {
+			return &csi.VolumeCondition{
+				Abnormal: true,
+				Message:  fmt.Sprintf("device %s is in state %s", mi.Source, strings.TrimSpace(string(state))),
+			}
+		}
+		return &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}
+	}
+	return nil
+}
+
+// isDeviceStateHealthy returns whether state read from device/state is the state of an operational device,
+// the device of an NVMe namespace is its controller which reports "live" instead of the SCSI "running".
+func isDeviceStateHealthy(deviceName, state string) bool {
+	if strings.HasPrefix(deviceName, "nvme") {
+		return state == "live"
+	}
+	return state == "running"
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
 func GetVolumeStats(ctx context.Context, m *mount.SafeFormatAndMount, target string, hostutil hostUtil) ([]*csi.VolumeUsage, error) {
 	var volUsages []*csi.VolumeUsage
 	_, err := os.Stat(target)
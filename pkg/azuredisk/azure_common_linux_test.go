@@ -17,10 +17,20 @@ limitations under the License.
 package azuredisk
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
+	"k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/crypt"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/mounter"
 )
 
 func TestRescanAllVolumes(t *testing.T) {
@@ -32,3 +42,260 @@ func TestRescanAllVolumes(t *testing.T) {
 		t.Errorf("rescanAllVolumes failed with error: %v", err)
 	}
 }
+
+func TestFindNVMeDiskByLun(t *testing.T) {
+	ioHandler := azureutils.NewFakeIOHandler()
+	tests := []struct {
+		lun      int
+		expected string
+	}{
+		{
+			lun:      1,
+			expected: "/dev/nvme0n3",
+		},
+		{
+			lun:      0,
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		device, err := findNVMeDiskByLun(test.lun, ioHandler)
+		if device != test.expected || err != nil {
+			t.Errorf("findNVMeDiskByLun(%d) = (%q, %v), expected (%q, nil)", test.lun, device, err, test.expected)
+		}
+	}
+}
+
+func TestFormatWithOptions(t *testing.T) {
+	unformattedAction := func() ([]byte, []byte, error) {
+		return []byte{}, []byte{}, &testingexec.FakeExitError{Status: 2}
+	}
+	formattedAction := func() ([]byte, []byte, error) {
+		return []byte("DEVICE=/dev/sdc\nTYPE=xfs"), []byte{}, nil
+	}
+	mkfsAction := func() ([]byte, []byte, error) {
+		return []byte{}, []byte{}, nil
+	}
+	mkfsFailedAction := func() ([]byte, []byte, error) {
+		return []byte("invalid option"), []byte{}, fmt.Errorf("exit status 1")
+	}
+
+	tests := []struct {
+		desc             string
+		fstype           string
+		options          []string
+		outputScripts    []testingexec.FakeAction
+		expectedCmds     int
+		expectedMkfsArgv []string
+		expectError      bool
+	}{
+		{
+			desc:             "unformatted disk is formatted with options",
+			fstype:           "xfs",
+			outputScripts:    []testingexec.FakeAction{unformattedAction, mkfsAction},
+			expectedCmds:     2,
+			expectedMkfsArgv: []string{"mkfs.xfs", "-f", "-m", "reflink=1", "/dev/sdc"},
+		},
+		{
+			desc:             "ext4 disk is formatted with the SafeFormatAndMount defaults before the options",
+			fstype:           "ext4",
+			outputScripts:    []testingexec.FakeAction{unformattedAction, mkfsAction},
+			expectedCmds:     2,
+			expectedMkfsArgv: []string{"mkfs.ext4", "-F", "-m0", "-m", "reflink=1", "/dev/sdc"},
+		},
+		{
+			desc:             "btrfs disk is formatted with the options only",
+			fstype:           "btrfs",
+			outputScripts:    []testingexec.FakeAction{unformattedAction, mkfsAction},
+			expectedCmds:     2,
+			expectedMkfsArgv: []string{"mkfs.btrfs", "-m", "reflink=1", "/dev/sdc"},
+		},
+		{
+			desc:          "formatted disk is not formatted again",
+			fstype:        "xfs",
+			outputScripts: []testingexec.FakeAction{formattedAction},
+			expectedCmds:  1,
+		},
+		{
+			desc:         "read-only mount is not formatted",
+			fstype:       "xfs",
+			options:      []string{"ro"},
+			expectedCmds: 0,
+		},
+		{
+			desc:             "mkfs failure",
+			fstype:           "xfs",
+			outputScripts:    []testingexec.FakeAction{unformattedAction, mkfsFailedAction},
+			expectedCmds:     2,
+			expectedMkfsArgv: []string{"mkfs.xfs", "-f", "-m", "reflink=1", "/dev/sdc"},
+			expectError:      true,
+		},
+	}
+
+	for _, test := range tests {
+		m, _ := mounter.NewFakeSafeMounter()
+		fakeExec := m.Exec.(*mounter.FakeSafeMounter)
+		fakeExec.SetNextCommandOutputScripts(test.outputScripts...)
+		var mkfsArgv []string
+		for i := range fakeExec.CommandScript {
+			script := fakeExec.CommandScript[i]
+			fakeExec.CommandScript[i] = func(cmd string, args ...string) exec.Cmd {
+				if strings.HasPrefix(cmd, "mkfs.") {
+					mkfsArgv = append([]string{cmd}, args...)
+				}
+				return script(cmd, args...)
+			}
+		}
+
+		err := formatWithOptions("/dev/sdc", test.fstype, test.options, []string{"-m", "reflink=1"}, m)
+		if (err != nil) != test.expectError {
+			t.Errorf("desc: %s, unexpected error: %v", test.desc, err)
+		}
+		if fakeExec.CommandCalls != test.expectedCmds {
+			t.Errorf("desc: %s, expected %d commands, got %d", test.desc, test.expectedCmds, fakeExec.CommandCalls)
+		}
+		if !reflect.DeepEqual(mkfsArgv, test.expectedMkfsArgv) {
+			t.Errorf("desc: %s, expected mkfs argv %v, got %v", test.desc, test.expectedMkfsArgv, mkfsArgv)
+		}
+	}
+}
+
+func TestOpenLuksDevice(t *testing.T) {
+	unformattedAction := func() ([]byte, []byte, error) {
+		return []byte{}, []byte{}, &testingexec.FakeExitError{Status: 2}
+	}
+	luksAction := func() ([]byte, []byte, error) {
+		return []byte("DEVICE=/dev/sdc\nTYPE=crypto_LUKS"), []byte{}, nil
+	}
+	ext4Action := func() ([]byte, []byte, error) {
+		return []byte("DEVICE=/dev/sdc\nTYPE=ext4"), []byte{}, nil
+	}
+	cryptsetupAction := func() ([]byte, []byte, error) {
+		return []byte{}, []byte{}, nil
+	}
+	diskURI := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/disk"
+
+	tests := []struct {
+		desc          string
+		outputScripts []testingexec.FakeAction
+		expectedCmds  int
+		expectError   bool
+	}{
+		{
+			desc:          "unformatted disk is formatted and opened",
+			outputScripts: []testingexec.FakeAction{unformattedAction, cryptsetupAction, cryptsetupAction},
+			expectedCmds:  3,
+		},
+		{
+			desc:          "LUKS disk is opened",
+			outputScripts: []testingexec.FakeAction{luksAction, cryptsetupAction},
+			expectedCmds:  2,
+		},
+		{
+			desc:          "disk with filesystem is not encrypted",
+			outputScripts: []testingexec.FakeAction{ext4Action},
+			expectedCmds:  1,
+			expectError:   true,
+		},
+	}
+
+	for _, test := range tests {
+		m, _ := mounter.NewFakeSafeMounter()
+		fakeExec := m.Exec.(*mounter.FakeSafeMounter)
+		fakeExec.SetNextCommandOutputScripts(test.outputScripts...)
+
+		devicePath, err := openLuksDevice("/dev/sdc", diskURI, "secret", m)
+		if (err != nil) != test.expectError {
+			t.Errorf("desc: %s, unexpected error: %v", test.desc, err)
+		}
+		if err == nil && devicePath != crypt.MapperPath(diskURI) {
+			t.Errorf("desc: %s, unexpected device path: %s", test.desc, devicePath)
+		}
+		if fakeExec.CommandCalls != test.expectedCmds {
+			t.Errorf("desc: %s, expected %d commands, got %d", test.desc, test.expectedCmds, fakeExec.CommandCalls)
+		}
+	}
+}
+
+// stateIOHandler serves device states from files and fails for any other path
+type stateIOHandler struct {
+	azureutils.IOHandler
+	files map[string]string
+}
+
+func (h *stateIOHandler) ReadFile(filename string) ([]byte, error) {
+	if content, ok := h.files[filename]; ok {
+		return []byte(content), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func TestGetVolumeCondition(t *testing.T) {
+	mountInfo := `36 35 98:0 / /mnt/healthy rw,noatime master:1 - ext4 /dev/sdz rw,errors=continue
+37 35 98:1 / /mnt/remounted rw,noatime - ext4 /dev/sdy ro,errors=remount-ro
+38 35 98:2 / /mnt/readonly ro,noatime - ext4 /dev/sdx ro
+39 35 98:3 / /mnt/offline rw,noatime - ext4 /dev/sdw rw
+40 35 259:0 / /mnt/nvme rw,noatime - ext4 /dev/nvme0n1 rw
+41 35 259:1 / /mnt/nvmedead rw,noatime - ext4 /dev/nvme1n1 rw
+`
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(mountInfoPath, []byte(mountInfo), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", mountInfoPath, err)
+	}
+	originalPath := procMountInfoPath
+	procMountInfoPath = mountInfoPath
+	defer func() { procMountInfoPath = originalPath }()
+
+	io := &stateIOHandler{files: map[string]string{
+		"/sys/class/block/sdz/device/state":     "running\n",
+		"/sys/class/block/sdw/device/state":     "offline\n",
+		"/sys/class/block/nvme0n1/device/state": "live\n",
+		"/sys/class/block/nvme1n1/device/state": "dead\n",
+	}}
+
+	tests := []struct {
+		target           string
+		expectedNil      bool
+		expectedAbnormal bool
+	}{
+		{
+			target: "/mnt/healthy",
+		},
+		{
+			target:           "/mnt/remounted/",
+			expectedAbnormal: true,
+		},
+		{
+			target: "/mnt/readonly",
+		},
+		{
+			target:           "/mnt/offline",
+			expectedAbnormal: true,
+		},
+		{
+			target: "/mnt/nvme",
+		},
+		{
+			target:           "/mnt/nvmedead",
+			expectedAbnormal: true,
+		},
+		{
+			target:      "/mnt/notmounted",
+			expectedNil: true,
+		},
+	}
+
+	for _, test := range tests {
+		condition := getVolumeCondition(test.target, io)
+		if test.expectedNil {
+			if condition != nil {
+				t.Errorf("target: %s, expected nil condition, got %v", test.target, condition)
+			}
+			continue
+		}
+		if condition == nil || condition.Abnormal != test.expectedAbnormal {
+			t.Errorf("target: %s, expected abnormal %v, got %v", test.target, test.expectedAbnormal, condition)
+		}
+	}
+}
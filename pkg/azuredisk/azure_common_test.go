@@ -17,8 +17,13 @@ limitations under the License.
 package azuredisk
 
 import (
+	"context"
+	"errors"
 	"runtime"
 	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
 )
@@ -61,3 +66,92 @@ func TestStrFirstLetterToUpper(t *testing.T) {
 		t.Errorf("result wrong")
 	}
 }
+
+func TestIsTransientResizeError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{
+			err:      nil,
+			expected: false,
+		},
+		{
+			err:      errors.New("resize2fs: Device or resource busy while trying to open /dev/sdc"),
+			expected: true,
+		},
+		{
+			err:      errors.New("xfs_growfs: Resource temporarily unavailable"),
+			expected: true,
+		},
+		{
+			err:      errors.New("resize of format ntfs is not supported"),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		if result := isTransientResizeError(test.err); result != test.expected {
+			t.Errorf("isTransientResizeError(%v) = %v, expected %v", test.err, result, test.expected)
+		}
+	}
+}
+
+func TestRetryTransientResize(t *testing.T) {
+	busyErr := errors.New("resize2fs: Device or resource busy while trying to open /dev/sdc")
+	fatalErr := errors.New("resize of format ntfs is not supported")
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 1.0, Steps: 3}
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		desc          string
+		ctx           context.Context
+		errs          []error
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{
+			desc:          "succeeds after transient errors",
+			ctx:           context.Background(),
+			errs:          []error{busyErr, busyErr, nil},
+			expectedCalls: 3,
+		},
+		{
+			desc:          "non-transient error is not retried",
+			ctx:           context.Background(),
+			errs:          []error{fatalErr},
+			expectedCalls: 1,
+			expectedErr:   true,
+		},
+		{
+			desc:          "transient errors until backoff is exhausted",
+			ctx:           context.Background(),
+			errs:          []error{busyErr, busyErr, busyErr, nil},
+			expectedCalls: 3,
+			expectedErr:   true,
+		},
+		{
+			desc:          "cancelled context stops retries",
+			ctx:           cancelledCtx,
+			errs:          []error{nil},
+			expectedCalls: 0,
+			expectedErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := retryTransientResize(test.ctx, backoff, func() error {
+			err := test.errs[calls]
+			calls++
+			return err
+		})
+		if (err != nil) != test.expectedErr {
+			t.Errorf("desc: %s, unexpected error: %v", test.desc, err)
+		}
+		if calls != test.expectedCalls {
+			t.Errorf("desc: %s, expected %d calls, got %d", test.desc, test.expectedCalls, calls)
+		}
+	}
+}
@@ -33,7 +33,10 @@ import (
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/mounter"
 )
 
-func formatAndMount(source, target, fstype string, options []string, m *mount.SafeFormatAndMount) error {
+func formatAndMount(source, target, fstype string, options, formatOptions []string, m *mount.SafeFormatAndMount) error {
+	if len(formatOptions) > 0 {
+		return fmt.Errorf("format options(%s) are not supported on Windows", formatOptions)
+	}
 	if proxy, ok := m.Interface.(mounter.CSIProxyMounter); ok {
 		return proxy.FormatAndMount(source, target, fstype, options)
 	}
@@ -169,3 +172,19 @@ func GetVolumeStats(ctx context.Context, m *mount.SafeFormatAndMount, target str
 	}
 	return []*csi.VolumeUsage{}, fmt.Errorf("could not cast to csi proxy class")
 }
+
+func openLuksDevice(source, diskURI, passphrase string, m *mount.SafeFormatAndMount) (string, error) {
+	return "", fmt.Errorf("LUKS encryption is not supported on Windows")
+}
+
+func closeLuksDevice(diskURI string, m *mount.SafeFormatAndMount) error {
+	return nil
+}
+
+func resizeLuksDevice(mapperName string, rescan bool, io azureutils.IOHandler, m *mount.SafeFormatAndMount) error {
+	return fmt.Errorf("LUKS encryption is not supported on Windows")
+}
+
+func getVolumeCondition(target string, io azureutils.IOHandler) *csi.VolumeCondition {
+	return nil
+}
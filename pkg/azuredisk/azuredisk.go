@@ -19,8 +19,8 @@ package azuredisk
 import (
 	"context"
 	"fmt"
+	"path"
 	"reflect"
-	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
@@ -30,14 +30,17 @@ import (
 	"google.golang.org/grpc/status"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/volume/util/hostutil"
 	"k8s.io/mount-utils"
 
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/audit"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
 	csicommon "sigs.k8s.io/azuredisk-csi-driver/pkg/csi-common"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/features"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/mounter"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/optimization"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
@@ -45,8 +48,16 @@ import (
 	azurecloudconsts "sigs.k8s.io/cloud-provider-azure/pkg/consts"
 	"sigs.k8s.io/cloud-provider-azure/pkg/provider"
 	azure "sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
+// getDiskThrottlingBackoff is how long GetDisk calls are skipped after throttling if ARM returns no Retry-After
+const getDiskThrottlingBackoff = 5 * time.Minute
+
+// cloneSourceSnapshotDeleteTimeout bounds the deletion of the intermediate snapshot of a clone, which must not depend
+// on the CreateVolume request context since csi-provisioner may cancel it before the cleanup runs
+const cloneSourceSnapshotDeleteTimeout = 5 * time.Minute
+
 // DriverOptions defines driver parameters specified in driver deployment
 type DriverOptions struct {
 	NodeID                     string
@@ -67,7 +78,10 @@ type DriverOptions struct {
 	GetNodeInfoFromLabels      bool
 	EnableDiskCapacityCheck    bool
 	VMSSCacheTTLInSeconds      int64
+	DiskCacheTTLInSeconds      int64
 	VMType                     string
+	AllowedPVCAnnotationParams string
+	AuditLogPath               string
 }
 
 // CSIDriver defines the interface for a CSI driver.
@@ -109,6 +123,9 @@ type DriverCore struct {
 	enableDiskCapacityCheck    bool
 	vmssCacheTTLInSeconds      int64
 	vmType                     string
+	allowedPVCAnnotationParams sets.String
+	auditLogPath               string
+	auditLogger                *audit.Logger
 }
 
 // Driver is the v1 implementation of the Azure Disk CSI Driver.
@@ -117,6 +134,8 @@ type Driver struct {
 	volumeLocks *volumehelper.VolumeLocks
 	// a timed cache GetDisk throttling
 	getDiskThrottlingCache *azcache.TimedCache
+	// a TTL cache of managed disk GET results
+	diskCache *azureutils.DiskCache
 }
 
 // newDriverV1 Creates a NewCSIDriver object. Assumes vendor version is equal to driver version &
@@ -143,19 +162,22 @@ func newDriverV1(options *DriverOptions) *Driver {
 	driver.enableDiskCapacityCheck = options.EnableDiskCapacityCheck
 	driver.vmssCacheTTLInSeconds = options.VMSSCacheTTLInSeconds
 	driver.vmType = options.VMType
+	driver.allowedPVCAnnotationParams = azureutils.GetAllowedPVCAnnotationParameters(options.AllowedPVCAnnotationParams)
+	driver.auditLogPath = options.AuditLogPath
 	driver.volumeLocks = volumehelper.NewVolumeLocks()
 	driver.ioHandler = azureutils.NewOSIOHandler()
 	driver.hostUtil = hostutil.NewHostUtil()
 
 	topologyKey = fmt.Sprintf("topology.%s/zone", driver.Name)
 
-	cache, err := azcache.NewTimedcache(5*time.Minute, func(key string) (interface{}, error) {
+	cache, err := azcache.NewTimedcache(getDiskThrottlingBackoff, func(key string) (interface{}, error) {
 		return nil, nil
 	})
 	if err != nil {
 		klog.Fatalf("%v", err)
 	}
 	driver.getDiskThrottlingCache = cache
+	driver.diskCache = azureutils.NewDiskCache(time.Duration(options.DiskCacheTTLInSeconds) * time.Second)
 	return &driver
 }
 
@@ -177,6 +199,12 @@ func (d *Driver) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMock
 	d.cloud = cloud
 	d.kubeconfig = kubeconfig
 
+	if d.auditLogPath != "" {
+		if d.auditLogger, err = audit.NewLogger(d.auditLogPath); err != nil {
+			klog.Fatalf("failed to open audit log %s, error: %v", d.auditLogPath, err)
+		}
+	}
+
 	if d.vmType != "" {
 		klog.V(2).Infof("override VMType(%s) in cloud config as %s", d.cloud.VMType, d.vmType)
 		d.cloud.VMType = d.vmType
@@ -243,12 +271,16 @@ func (d *Driver) Run(endpoint, kubeconfig string, disableAVSetNodes, testingMock
 			csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
 			csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
 		})
-	d.AddNodeServiceCapabilities([]csi.NodeServiceCapability_RPC_Type{
+	nodeCap := []csi.NodeServiceCapability_RPC_Type{
 		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
 		csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
 		csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
 		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
-	})
+	}
+	if features.DefaultFeatureGate.Enabled(features.VolumeCondition) {
+		nodeCap = append(nodeCap, csi.NodeServiceCapability_RPC_VOLUME_CONDITION)
+	}
+	d.AddNodeServiceCapabilities(nodeCap)
 
 	s := csicommon.NewNonBlockingGRPCServer()
 	// Driver d act as IdentityServer, ControllerServer and NodeServer
@@ -262,9 +294,17 @@ func (d *Driver) isGetDiskThrottled() bool {
 		klog.Warningf("getDiskThrottlingCache(%s) return with error: %s", consts.ThrottlingKey, err)
 		return false
 	}
+	if until, ok := cache.(time.Time); ok {
+		return time.Now().Before(until)
+	}
 	return cache != nil
 }
 
+// setGetDiskThrottled skips GetDisk calls until the throttling reported by rerr is expected to be over
+func (d *Driver) setGetDiskThrottled(rerr *retry.Error) {
+	d.getDiskThrottlingCache.Set(consts.ThrottlingKey, time.Now().Add(azureutils.GetThrottlingBackoff(rerr, getDiskThrottlingBackoff)))
+}
+
 func (d *Driver) checkDiskExists(ctx context.Context, diskURI string) (*compute.Disk, error) {
 	diskName, err := azureutils.GetDiskName(diskURI)
 	if err != nil {
@@ -283,9 +323,9 @@ func (d *Driver) checkDiskExists(ctx context.Context, diskURI string) (*compute.
 	subsID := azureutils.GetSubscriptionIDFromURI(diskURI)
 	disk, rerr := d.cloud.DisksClient.Get(ctx, subsID, resourceGroup, diskName)
 	if rerr != nil {
-		if rerr.IsThrottled() || strings.Contains(rerr.RawError.Error(), consts.RateLimited) {
+		if rerr.IsThrottled() || azureutils.IsThrottlingError(rerr.RawError) {
 			klog.Warningf("checkDiskExists(%s) is throttled with error: %v", diskURI, rerr.Error())
-			d.getDiskThrottlingCache.Set(consts.ThrottlingKey, "")
+			d.setGetDiskThrottled(rerr)
 			return nil, nil
 		}
 		return nil, rerr.Error()
@@ -300,7 +340,7 @@ func (d *Driver) checkDiskCapacity(ctx context.Context, subsID, resourceGroup, d
 		return true, nil
 	}
 
-	disk, rerr := d.cloud.DisksClient.Get(ctx, subsID, resourceGroup, diskName)
+	disk, rerr := d.getDisk(ctx, subsID, resourceGroup, diskName)
 	// Because we can not judge the reason of the error. Maybe the disk does not exist.
 	// So here we do not handle the error.
 	if rerr == nil {
@@ -308,14 +348,25 @@ func (d *Driver) checkDiskCapacity(ctx context.Context, subsID, resourceGroup, d
 			return false, status.Errorf(codes.AlreadyExists, "the request volume already exists, but its capacity(%v) is different from (%v)", *disk.DiskProperties.DiskSizeGB, requestGiB)
 		}
 	} else {
-		if rerr.IsThrottled() || strings.Contains(rerr.RawError.Error(), consts.RateLimited) {
+		if rerr.IsThrottled() || azureutils.IsThrottlingError(rerr.RawError) {
 			klog.Warningf("checkDiskCapacity(%s, %s) is throttled with error: %v", resourceGroup, diskName, rerr.Error())
-			d.getDiskThrottlingCache.Set(consts.ThrottlingKey, "")
+			d.setGetDiskThrottled(rerr)
 		}
 	}
 	return true, nil
 }
 
+// getDisk gets the managed disk, serving it from the disk cache when caching is enabled
+func (d *Driver) getDisk(ctx context.Context, subsID, resourceGroup, diskName string) (compute.Disk, *retry.Error) {
+	if subsID == "" {
+		subsID = d.cloud.SubscriptionID
+	}
+	diskURI := fmt.Sprintf(consts.ManagedDiskPath, subsID, resourceGroup, diskName)
+	return d.diskCache.Get(diskURI, func() (compute.Disk, *retry.Error) {
+		return d.cloud.DisksClient.Get(ctx, subsID, resourceGroup, diskName)
+	})
+}
+
 func (d *Driver) getVolumeLocks() *volumehelper.VolumeLocks {
 	return d.volumeLocks
 }
@@ -405,3 +456,136 @@ func getNodeInfoFromLabels(ctx context.Context, nodeName string, kubeClient clie
 	}
 	return node.Labels[consts.WellKnownTopologyKey], node.Labels[consts.InstanceTypeKey], nil
 }
+
+// getTagsFromLabels gets tags for the label keys in tagsFromLabels from the labels of the PVC and its namespace, PVC labels take precedence
+func getTagsFromLabels(ctx context.Context, kubeClient clientset.Interface, tagsFromLabels, pvcName, pvcNamespace string) (map[string]string, error) {
+	if kubeClient == nil || kubeClient.CoreV1() == nil {
+		return nil, fmt.Errorf("kubeClient is nil")
+	}
+
+	namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, pvcNamespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get namespace(%s) failed with %v", pvcNamespace, err)
+	}
+
+	pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get pvc(%s/%s) failed with %v", pvcNamespace, pvcName, err)
+	}
+
+	return azureutils.GetTagsFromLabels(tagsFromLabels, namespace.Labels, pvc.Labels), nil
+}
+
+// getParametersFromPVCAnnotations applies the allowed "disk.csi.azure.com/<parameter>" annotations of the PVC to parameters
+func getParametersFromPVCAnnotations(ctx context.Context, kubeClient clientset.Interface, parameters map[string]string, allowedParameters sets.String) (map[string]string, error) {
+	pvcName, pvcNamespace := parameters[consts.PvcNameKey], parameters[consts.PvcNamespaceKey]
+	if pvcName == "" || pvcNamespace == "" {
+		// PVC metadata is only passed with --extra-create-metadata in csi-provisioner
+		klog.V(2).Infof("skip PVC annotation parameters since PVC name or namespace is not provided")
+		return parameters, nil
+	}
+	if kubeClient == nil || kubeClient.CoreV1() == nil {
+		return nil, fmt.Errorf("kubeClient is nil")
+	}
+
+	pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get pvc(%s/%s) failed with %v", pvcNamespace, pvcName, err)
+	}
+
+	return azureutils.OverrideParametersFromPVCAnnotations(parameters, pvc.Annotations, allowedParameters)
+}
+
+// updateDisk applies diskUpdate to the managed disk identified by diskURI.
+func updateDisk(ctx context.Context, cloud *provider.Cloud, diskURI string, diskUpdate compute.DiskUpdate) error {
+	diskName, err := azureutils.GetDiskName(diskURI)
+	if err != nil {
+		return err
+	}
+
+	resourceGroup, err := azureutils.GetResourceGroupFromURI(diskURI)
+	if err != nil {
+		return err
+	}
+
+	subsID := azureutils.GetSubscriptionIDFromURI(diskURI)
+	if rerr := cloud.DisksClient.Update(ctx, subsID, resourceGroup, diskName, diskUpdate); rerr != nil {
+		return rerr.Error()
+	}
+	return nil
+}
+
+// isDiskAttached returns whether the managed disk identified by diskURI is attached to a VM
+func isDiskAttached(ctx context.Context, cloud *provider.Cloud, diskURI string) (bool, error) {
+	resourceGroup, err := azureutils.GetResourceGroupFromURI(diskURI)
+	if err != nil {
+		return false, err
+	}
+	disk, rerr := cloud.DisksClient.Get(ctx, azureutils.GetSubscriptionIDFromURI(diskURI), resourceGroup, path.Base(diskURI))
+	if rerr != nil {
+		return false, rerr.Error()
+	}
+	return disk.ManagedBy != nil || (disk.DiskProperties != nil && disk.DiskProperties.DiskState == compute.DiskStateAttached), nil
+}
+
+// createCloneSourceSnapshot creates a temporary snapshot of sourceDiskURI to clone an attached disk from
+func createCloneSourceSnapshot(ctx context.Context, cloud *provider.Cloud, auditLogger *audit.Logger, diskParams azureutils.ManagedDiskParameters, sourceDiskURI string) (string, error) {
+	snapshotName := azureutils.CreateValidDiskName(diskParams.DiskName + "-clone-source")
+	location := diskParams.Location
+	if location == "" {
+		location = cloud.Location
+	}
+	incremental := !azureutils.IsAzureStackCloud(cloud.Config.Cloud, cloud.Config.DisableAzureStackCloud)
+	snapshot := compute.Snapshot{
+		SnapshotProperties: &compute.SnapshotProperties{
+			CreationData: &compute.CreationData{
+				CreateOption:     compute.DiskCreateOptionCopy,
+				SourceResourceID: &sourceDiskURI,
+			},
+			Incremental: &incremental,
+		},
+		Location: &location,
+	}
+
+	subsID := diskParams.SubscriptionID
+	if subsID == "" {
+		subsID = cloud.SubscriptionID
+	}
+	snapshotID := fmt.Sprintf(consts.ManagedSnapshotPath, subsID, diskParams.ResourceGroup, snapshotName)
+
+	klog.V(2).Infof("source disk(%s) is attached, begin to create intermediate snapshot(%s) under rg(%s)", sourceDiskURI, snapshotName, diskParams.ResourceGroup)
+	auditOp := auditLogger.StartOperation("controller_create_clone_source_snapshot")
+	rerr := cloud.SnapshotsClient.CreateOrUpdate(ctx, diskParams.SubscriptionID, diskParams.ResourceGroup, snapshotName, snapshot)
+	auditOp.Finish(rerr == nil, consts.SourceResourceID, sourceDiskURI, consts.SnapshotID, snapshotID)
+	if rerr != nil {
+		// the snapshot may have been accepted by ARM before the request failed or ctx was cancelled
+		deleteCloneSourceSnapshot(cloud, auditLogger, snapshotID)
+		return "", azureutils.NewStatusFromAzureError(rerr.Error(), fmt.Sprintf("failed to create intermediate snapshot(%s) of source disk(%s)", snapshotName, sourceDiskURI))
+	}
+	return snapshotID, nil
+}
+
+// deleteCloneSourceSnapshot deletes the temporary snapshot created by createCloneSourceSnapshot, with its own
+// context so that the snapshot is not leaked when the CreateVolume request context is already done
+func deleteCloneSourceSnapshot(cloud *provider.Cloud, auditLogger *audit.Logger, snapshotID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cloneSourceSnapshotDeleteTimeout)
+	defer cancel()
+	snapshotName, err := azureutils.GetSnapshotNameFromURI(snapshotID)
+	if err != nil {
+		klog.Errorf("failed to parse intermediate snapshot(%s): %v", snapshotID, err)
+		return
+	}
+	resourceGroup, err := azureutils.GetResourceGroupFromURI(snapshotID)
+	if err != nil {
+		klog.Errorf("failed to parse intermediate snapshot(%s): %v", snapshotID, err)
+		return
+	}
+	auditOp := auditLogger.StartOperation("controller_delete_clone_source_snapshot")
+	rerr := cloud.SnapshotsClient.Delete(ctx, azureutils.GetSubscriptionIDFromURI(snapshotID), resourceGroup, snapshotName)
+	auditOp.Finish(rerr == nil, consts.SnapshotID, snapshotID)
+	if rerr != nil {
+		klog.Errorf("failed to delete intermediate snapshot(%s): %v", snapshotID, rerr.Error())
+		return
+	}
+	klog.V(2).Infof("intermediate snapshot(%s) deleted", snapshotID)
+}
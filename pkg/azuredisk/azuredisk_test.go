@@ -28,6 +28,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clientset "k8s.io/client-go/kubernetes"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient/mockdiskclient"
@@ -55,6 +56,18 @@ func TestCheckDiskCapacity(t *testing.T) {
 	assert.Equal(t, err, expectedErr)
 }
 
+func TestGetParametersFromPVCAnnotations(t *testing.T) {
+	allowed := sets.NewString("cachingmode")
+	parameters := map[string]string{"cachingMode": "ReadOnly"}
+	result, err := getParametersFromPVCAnnotations(context.TODO(), nil, parameters, allowed)
+	assert.Nil(t, err)
+	assert.Equal(t, parameters, result)
+
+	parameters = map[string]string{consts.PvcNameKey: "pvc", consts.PvcNamespaceKey: "default"}
+	_, err = getParametersFromPVCAnnotations(context.TODO(), nil, parameters, allowed)
+	assert.Equal(t, fmt.Errorf("kubeClient is nil"), err)
+}
+
 func TestRun(t *testing.T) {
 	fakeCredFile := "fake-cred-file.json"
 	fakeCredContent := `{
@@ -21,13 +21,16 @@ package azuredisk
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient/mockdiskclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
 func TestCheckDiskCapacity_V1(t *testing.T) {
@@ -53,3 +56,15 @@ func TestDriver_checkDiskExists_V1(t *testing.T) {
 	_, err := d.checkDiskExists(context.TODO(), "testurl/subscriptions/12/resourceGroups/23/providers/Microsoft.Compute/disks/name")
 	assert.Equal(t, err, nil)
 }
+
+func TestIsGetDiskThrottled_V1(t *testing.T) {
+	d, _ := NewFakeDriver(t)
+	driver := d.(*fakeDriverV1)
+	assert.False(t, driver.isGetDiskThrottled())
+
+	driver.setGetDiskThrottled(&retry.Error{HTTPStatusCode: http.StatusTooManyRequests, RetryAfter: time.Now().Add(time.Minute)})
+	assert.True(t, driver.isGetDiskThrottled())
+
+	driver.getDiskThrottlingCache.Set(consts.ThrottlingKey, time.Now().Add(-time.Second))
+	assert.False(t, driver.isGetDiskThrottled())
+}
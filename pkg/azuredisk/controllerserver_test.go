@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
@@ -32,10 +34,14 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockcorev1"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockkubeclient"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mocknamespace"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockpersistentvolume"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk/mockpersistentvolumeclaim"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/features"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient/mockdiskclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/snapshotclient/mocksnapshotclient"
@@ -259,6 +265,47 @@ func TestCreateVolume(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "performance tier not supported by sku",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.SkuNameField] = "StandardSSD_LRS"
+				mp[consts.TierField] = "P30"
+				req := &csi.CreateVolumeRequest{
+					Name:               "unit-test",
+					VolumeCapabilities: createVolumeCapabilities(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER),
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "azureDisk - performance tier(P30) is not supported for sku/storageaccounttype StandardSSD_LRS. Supported values are [Premium_LRS Premium_ZRS]")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "bursting not supported by disk size",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.SkuNameField] = "Premium_LRS"
+				mp[consts.EnableBurstingField] = consts.TrueValue
+				req := &csi.CreateVolumeRequest{
+					Name:               "unit-test",
+					VolumeCapabilities: createVolumeCapabilities(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER),
+					CapacityRange: &csi.CapacityRange{
+						RequiredBytes: volumehelper.GiBToBytes(10),
+					},
+					Parameters: mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "azureDisk - on-demand bursting is only supported on disks larger than 512 GiB, requested size: 10 GiB")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
 		{
 			name: "custom tags error ",
 			testFunc: func(t *testing.T) {
@@ -416,6 +463,570 @@ func TestCreateVolume(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "valid request with performance tier",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.SkuNameField] = "Premium_LRS"
+				mp[consts.TierField] = "P30"
+				stdCapacityRangetest := &csi.CapacityRange{
+					RequiredBytes: volumehelper.GiBToBytes(10),
+					LimitBytes:    volumehelper.GiBToBytes(15),
+				}
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      stdCapacityRangetest,
+					Parameters:         mp,
+				}
+				size := int32(volumehelper.BytesToGiB(req.CapacityRange.RequiredBytes))
+				id := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", testVolumeName)
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:   &id,
+					Name: &testVolumeName,
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        &size,
+						ProvisioningState: &state,
+					},
+				}
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), testVolumeName, compute.DiskUpdate{
+					DiskUpdateProperties: &compute.DiskUpdateProperties{
+						Tier: to.StringPtr("P30"),
+					},
+				}).Return(nil).Times(1)
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := error(nil)
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "valid request with publicNetworkAccess",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.PublicNetworkAccessField] = "Disabled"
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				size := int32(volumehelper.BytesToGiB(req.CapacityRange.RequiredBytes))
+				id := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", testVolumeName)
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:   &id,
+					Name: &testVolumeName,
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        &size,
+						ProvisioningState: &state,
+					},
+				}
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), testVolumeName, compute.DiskUpdate{
+					DiskUpdateProperties: &compute.DiskUpdateProperties{
+						PublicNetworkAccess: compute.PublicNetworkAccessDisabled,
+					},
+				}).Return(nil).Times(1)
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := error(nil)
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "mount option not supported by fsType",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.FsTypeField] = "ext4"
+				req := &csi.CreateVolumeRequest{
+					Name: testVolumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						{
+							AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{MountFlags: []string{"noatime", "nouuid"}}},
+							AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+						},
+					},
+					Parameters: mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "azureDisk - mount option nouuid is not supported by fsType ext4, it is only supported by xfs")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "LUKS encryption with block volume",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.EncryptedField] = consts.LuksEncryption
+				req := &csi.CreateVolumeRequest{
+					Name: testVolumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						{
+							AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+							AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+						},
+					},
+					Parameters: mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "LUKS encryption is not supported for block volumes")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "LUKS encryption with LUKSEncryption feature gate disabled",
+			testFunc: func(t *testing.T) {
+				assert.Nil(t, features.DefaultMutableFeatureGate.Set("LUKSEncryption=false"))
+				defer func() {
+					assert.Nil(t, features.DefaultMutableFeatureGate.Set("LUKSEncryption=true"))
+				}()
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.EncryptedField] = consts.LuksEncryption
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "encrypted is not supported since feature gate LUKSEncryption is disabled")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "tagsFromLabels without PVC metadata",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.TagsFromLabelsField] = "team"
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "tagsfromlabels requires PVC name and namespace, enable --extra-create-metadata in csi-provisioner")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "valid request with tagsFromLabels",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				corev1 := mockcorev1.NewMockInterface(ctrl)
+				namespaces := mocknamespace.NewMockInterface(ctrl)
+				pvcs := mockpersistentvolumeclaim.NewMockInterface(ctrl)
+				d.getCloud().KubeClient = mockkubeclient.NewMockInterface(ctrl)
+				d.getCloud().KubeClient.(*mockkubeclient.MockInterface).EXPECT().CoreV1().Return(corev1).AnyTimes()
+				corev1.EXPECT().Namespaces().Return(namespaces).AnyTimes()
+				corev1.EXPECT().PersistentVolumeClaims("ns").Return(pvcs).AnyTimes()
+				namespaces.EXPECT().Get(gomock.Any(), "ns", gomock.Any()).
+					Return(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{"team": "ns-team", "cost-center": "cc1"}}}, nil).Times(1)
+				pvcs.EXPECT().Get(gomock.Any(), "pvc", gomock.Any()).
+					Return(&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "ns", Labels: map[string]string{"team": "pvc-team", "app": "web"}}}, nil).AnyTimes()
+
+				mp := make(map[string]string)
+				mp[consts.TagsFromLabelsField] = "team,cost-center"
+				mp[consts.PvcNameKey] = "pvc"
+				mp[consts.PvcNamespaceKey] = "ns"
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				size := int32(volumehelper.BytesToGiB(req.CapacityRange.RequiredBytes))
+				id := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", testVolumeName)
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:   &id,
+					Name: &testVolumeName,
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        &size,
+						ProvisioningState: &state,
+					},
+				}
+				expectedTags := map[string]string{"team": "pvc-team", "cost-center": "cc1"}
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), testVolumeName, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, diskName string, disk compute.Disk) *retry.Error {
+						for k, v := range expectedTags {
+							if disk.Tags[k] == nil || *disk.Tags[k] != v {
+								t.Errorf("expected tag %s=%s on disk, got tags %v", k, v, disk.Tags)
+							}
+						}
+						if _, ok := disk.Tags["app"]; ok {
+							t.Errorf("unexpected tag app on disk, got tags %v", disk.Tags)
+						}
+						return nil
+					}).Times(1)
+				if _, err := d.CreateVolume(context.Background(), req); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "diskNameTemplate without PVC metadata",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.DiskNameTemplateField] = "{{.PVCNamespace}}-{{.PVCName}}-{{.Random}}"
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "disknametemplate requires PVC name and namespace, enable --extra-create-metadata in csi-provisioner")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "diskNameTemplate without PV specific value",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := map[string]string{
+					consts.DiskNameTemplateField: "{{.PVCNamespace}}-{{.PVCName}}",
+					consts.PvcNameKey:            "pvc",
+					consts.PvcNamespaceKey:       "ns",
+				}
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				if status.Code(err) != codes.InvalidArgument {
+					t.Errorf("expected InvalidArgument error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "diskNameTemplate renders a distinct disk name for PVs of the same PVC created concurrently",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				state := string(compute.ProvisioningStateSucceeded)
+				var lock sync.Mutex
+				diskNames := map[string]int{}
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, diskName string) (compute.Disk, *retry.Error) {
+						id := fmt.Sprintf(consts.ManagedDiskPath, subsID, resourceGroup, diskName)
+						return compute.Disk{
+							ID:   &id,
+							Name: &diskName,
+							DiskProperties: &compute.DiskProperties{
+								DiskSizeGB:        to.Int32Ptr(10),
+								ProvisioningState: &state,
+							},
+						}, nil
+					}).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, diskName string, disk compute.Disk) *retry.Error {
+						lock.Lock()
+						defer lock.Unlock()
+						diskNames[diskName]++
+						return nil
+					}).Times(2)
+
+				var wg sync.WaitGroup
+				for _, pvName := range []string{"pvc-a", "pvc-b"} {
+					req := &csi.CreateVolumeRequest{
+						Name:               pvName,
+						VolumeCapabilities: stdVolumeCapabilities,
+						CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+						Parameters: map[string]string{
+							consts.DiskNameTemplateField: "{{.PVCNamespace}}-{{.PVCName}}-{{.Random}}",
+							consts.PvcNameKey:            "pvc",
+							consts.PvcNamespaceKey:       "ns",
+							consts.PvNameKey:             pvName,
+						},
+					}
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if _, err := d.CreateVolume(context.Background(), req); err != nil {
+							t.Errorf("unexpected error: %v", err)
+						}
+					}()
+				}
+				wg.Wait()
+				if len(diskNames) != 2 {
+					t.Errorf("expected a distinct disk for every PV, got %v", diskNames)
+				}
+			},
+		},
+		{
+			name: "diskAccessID without AllowPrivate networkAccessPolicy",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				mp := make(map[string]string)
+				mp[consts.DiskAccessIDField] = "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/diskAccesses/access"
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         mp,
+				}
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Error(codes.InvalidArgument, "azureDisk - diskAccessID is only supported with networkAccessPolicy AllowPrivate, requested: AllowAll")
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "requested size smaller than source snapshot",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				snapshotID := fmt.Sprintf("/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/snapshots/%s", testVolumeName)
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshotID},
+						},
+					},
+				}
+				snapshot := compute.Snapshot{
+					SnapshotProperties: &compute.SnapshotProperties{DiskSizeGB: to.Int32Ptr(20)},
+				}
+				d.getCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface).EXPECT().Get(gomock.Any(), "subs", "rg", testVolumeName).Return(snapshot, nil).Times(1)
+				_, err := d.CreateVolume(context.Background(), req)
+				expectedErr := status.Errorf(codes.InvalidArgument, "requested size(10 GiB) is smaller than the size(20 GiB) of source snapshot(%s)", snapshotID)
+				if !reflect.DeepEqual(err, expectedErr) {
+					t.Errorf("actualErr: (%v), expectedErr: (%v)", err, expectedErr)
+				}
+			},
+		},
+		{
+			name: "restore from smaller snapshot requires resize",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				snapshotID := fmt.Sprintf("/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/snapshots/%s", testVolumeName)
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(20)},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshotID},
+						},
+					},
+				}
+				snapshot := compute.Snapshot{
+					SnapshotProperties: &compute.SnapshotProperties{DiskSizeGB: to.Int32Ptr(10)},
+				}
+				id := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", testVolumeName)
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:   &id,
+					Name: &testVolumeName,
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        to.Int32Ptr(20),
+						ProvisioningState: &state,
+					},
+				}
+				d.getCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface).EXPECT().Get(gomock.Any(), "subs", "rg", testVolumeName).Return(snapshot, nil).Times(1)
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				res, err := d.CreateVolume(context.Background(), req)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if res.Volume.VolumeContext[consts.ResizeRequired] != "true" {
+					t.Errorf("expected %s to be true in volume context: %v", consts.ResizeRequired, res.Volume.VolumeContext)
+				}
+			},
+		},
+		{
+			name: "clone attached disk through intermediate snapshot",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				sourceID := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", "source-disk")
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         map[string]string{consts.ResourceGroupField: "rg"},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: sourceID},
+						},
+					},
+				}
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:        &sourceID,
+					ManagedBy: to.StringPtr("vm"),
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        to.Int32Ptr(10),
+						DiskState:         compute.DiskStateAttached,
+						ProvisioningState: &state,
+					},
+				}
+				snapshotName := testVolumeName + "-clone-source"
+				mockSnapshotClient := d.getCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface)
+				mockSnapshotClient.EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), "rg", snapshotName, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, snapshotName string, snapshot compute.Snapshot) *retry.Error {
+						if snapshot.Location == nil || *snapshot.Location != d.getCloud().Location {
+							t.Errorf("expected intermediate snapshot in location %s, got %v", d.getCloud().Location, snapshot.Location)
+						}
+						return nil
+					}).Times(1)
+				mockSnapshotClient.EXPECT().Delete(gomock.Any(), d.getCloud().SubscriptionID, "rg", snapshotName).Return(nil).Times(1)
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), "rg", testVolumeName, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, diskName string, disk compute.Disk) *retry.Error {
+						if disk.CreationData == nil || disk.CreationData.SourceResourceID == nil || !strings.HasSuffix(*disk.CreationData.SourceResourceID, "/snapshots/"+snapshotName) {
+							t.Errorf("expected disk to be created from intermediate snapshot, got %+v", disk.CreationData)
+						}
+						return nil
+					}).Times(1)
+				if _, err := d.CreateVolume(context.Background(), req); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "clone deletes intermediate snapshot after the request context is cancelled",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				sourceID := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", "source-disk")
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         map[string]string{consts.ResourceGroupField: "rg"},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: sourceID},
+						},
+					},
+				}
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:        &sourceID,
+					ManagedBy: to.StringPtr("vm"),
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        to.Int32Ptr(10),
+						DiskState:         compute.DiskStateAttached,
+						ProvisioningState: &state,
+					},
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				snapshotName := testVolumeName + "-clone-source"
+				mockSnapshotClient := d.getCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface)
+				mockSnapshotClient.EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), "rg", snapshotName, gomock.Any()).Return(nil).Times(1)
+				mockSnapshotClient.EXPECT().Delete(gomock.Any(), d.getCloud().SubscriptionID, "rg", snapshotName).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, snapshotName string) *retry.Error {
+						if ctx.Err() != nil {
+							t.Errorf("expected intermediate snapshot to be deleted with a live context, got %v", ctx.Err())
+						}
+						return nil
+					}).Times(1)
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), "rg", testVolumeName, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, subsID, resourceGroup, diskName string, disk compute.Disk) *retry.Error {
+						cancel()
+						return &retry.Error{RawError: context.Canceled}
+					}).Times(1)
+				if _, err := d.CreateVolume(ctx, req); err == nil {
+					t.Errorf("expected an error when disk creation fails")
+				}
+			},
+		},
+		{
+			name: "clone deletes intermediate snapshot when its creation fails",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				sourceID := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", "source-disk")
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					Parameters:         map[string]string{consts.ResourceGroupField: "rg"},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: sourceID},
+						},
+					},
+				}
+				state := string(compute.ProvisioningStateSucceeded)
+				disk := compute.Disk{
+					ID:        &sourceID,
+					ManagedBy: to.StringPtr("vm"),
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        to.Int32Ptr(10),
+						DiskState:         compute.DiskStateAttached,
+						ProvisioningState: &state,
+					},
+				}
+				snapshotName := testVolumeName + "-clone-source"
+				mockSnapshotClient := d.getCloud().SnapshotsClient.(*mocksnapshotclient.MockInterface)
+				mockSnapshotClient.EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), "rg", snapshotName, gomock.Any()).
+					Return(&retry.Error{RawError: fmt.Errorf(`Code="AuthorizationFailed" Message="no access"`)}).Times(1)
+				mockSnapshotClient.EXPECT().Delete(gomock.Any(), d.getCloud().SubscriptionID, "rg", snapshotName).Return(nil).Times(1)
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(disk, nil).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				_, err := d.CreateVolume(context.Background(), req)
+				if status.Code(err) != codes.PermissionDenied {
+					t.Errorf("expected PermissionDenied error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "clone fails when source disk cannot be read",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				sourceID := fmt.Sprintf(consts.ManagedDiskPath, "subs", "rg", "source-disk")
+				req := &csi.CreateVolumeRequest{
+					Name:               testVolumeName,
+					VolumeCapabilities: stdVolumeCapabilities,
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: volumehelper.GiBToBytes(10)},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: sourceID},
+						},
+					},
+				}
+				rerr := &retry.Error{RawError: fmt.Errorf("test")}
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().Get(gomock.Any(), gomock.Any(), "rg", "source-disk").Return(compute.Disk{}, rerr).AnyTimes()
+				d.getCloud().DisksClient.(*mockdiskclient.MockInterface).EXPECT().CreateOrUpdate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				if _, err := d.CreateVolume(context.Background(), req); err == nil {
+					t.Errorf("expected an error when the source disk cannot be read")
+				}
+			},
+		},
 		{
 			name: "invalid parameter",
 			testFunc: func(t *testing.T) {
@@ -1987,6 +2598,34 @@ func TestGetSourceDiskSize(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "successful search: parent in another subscription and resource group",
+			testFunc: func(t *testing.T) {
+				d, _ := NewFakeDriver(t)
+				parentURI := "/subscriptions/other-subs/resourceGroups/other-rg/providers/Microsoft.Compute/disks/parent-disk"
+				disk1 := compute.Disk{
+					DiskProperties: &compute.DiskProperties{
+						CreationData: &compute.CreationData{
+							CreateOption:     compute.DiskCreateOptionCopy,
+							SourceResourceID: &parentURI,
+						},
+						DiskSizeGB: to.Int32Ptr(16),
+					},
+				}
+				disk2 := compute.Disk{
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB: to.Int32Ptr(8),
+					},
+				}
+				mockDisksClient := d.getCloud().DisksClient.(*mockdiskclient.MockInterface)
+				mockDisksClient.EXPECT().Get(gomock.Any(), "subs", "test-rg", "test-disk-1").Return(disk1, nil).Times(1)
+				mockDisksClient.EXPECT().Get(gomock.Any(), "other-subs", "other-rg", "parent-disk").Return(disk2, nil).Times(1)
+				size, err := d.GetSourceDiskSize(context.Background(), "subs", "test-rg", "test-disk-1", 0, 2)
+				if err != nil || size == nil || *size != 8 {
+					t.Errorf("actualOutput: (%v, %v), expectedOutput: (8, nil)", size, err)
+				}
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, tc.testFunc)
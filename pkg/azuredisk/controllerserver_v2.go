@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 
 	"google.golang.org/grpc/codes"
@@ -42,6 +43,7 @@ import (
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/features"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	"sigs.k8s.io/cloud-provider-azure/pkg/metrics"
 	azure "sigs.k8s.io/cloud-provider-azure/pkg/provider"
@@ -55,6 +57,12 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 	}
 
 	params := req.GetParameters()
+	if d.allowedPVCAnnotationParams.Len() > 0 {
+		var err error
+		if params, err = getParametersFromPVCAnnotations(ctx, d.cloud.KubeClient, params, d.allowedPVCAnnotationParams); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed applying PVC annotation parameters: %v", err)
+		}
+	}
 	diskParams, err := azureutils.ParseDiskParameters(params)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "Failed parsing disk parameters: %v", err)
@@ -73,6 +81,32 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 		return nil, status.Error(codes.InvalidArgument, "Volume capability not supported")
 	}
 
+	for _, c := range volCaps {
+		if mnt := c.GetMount(); mnt != nil {
+			fsType := diskParams.FsType
+			if fsType == "" {
+				fsType = mnt.GetFsType()
+			}
+			if fsType == "" {
+				continue
+			}
+			if err := azureutils.ValidateMountOptions(fsType, mnt.GetMountFlags()); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+	}
+
+	if diskParams.Encrypted == consts.LuksEncryption {
+		if !features.DefaultFeatureGate.Enabled(features.LUKSEncryption) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not supported since feature gate %s is disabled", consts.EncryptedField, features.LUKSEncryption)
+		}
+		for _, c := range volCaps {
+			if c.GetBlock() != nil {
+				return nil, status.Error(codes.InvalidArgument, "LUKS encryption is not supported for block volumes")
+			}
+		}
+	}
+
 	if acquired := d.volumeLocks.TryAcquire(name); !acquired {
 		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsFmt, name)
 	}
@@ -90,21 +124,40 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 		return nil, status.Error(codes.InvalidArgument, "After round-up, volume size exceeds the limit specified")
 	}
 
+	if diskParams.Location == "" {
+		diskParams.Location = d.cloud.Location
+	}
+
 	if azureutils.IsAzureStackCloud(d.cloud.Config.Cloud, d.cloud.Config.DisableAzureStackCloud) {
 		if diskParams.MaxShares > 1 {
 			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid maxShares value: %d as Azure Stack does not support shared disk.", diskParams.MaxShares))
 		}
+		if diskParams.Tier != "" {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid tier value: %s as Azure Stack does not support performance tiers.", diskParams.Tier))
+		}
+		if diskParams.PublicNetworkAccess != "" {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid publicNetworkAccess value: %s as Azure Stack does not support public network access.", diskParams.PublicNetworkAccess))
+		}
+	}
+
+	if diskParams.ResourceGroup == "" {
+		diskParams.ResourceGroup = d.cloud.ResourceGroup
 	}
 
+	if diskParams.DiskName == "" && diskParams.DiskNameTemplate != "" {
+		pvcName, pvcNamespace := diskParams.Tags[consts.PvcNameTag], diskParams.Tags[consts.PvcNamespaceTag]
+		if pvcName == "" || pvcNamespace == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s requires PVC name and namespace, enable --extra-create-metadata in csi-provisioner", consts.DiskNameTemplateField)
+		}
+		if diskParams.DiskName, err = azureutils.GenerateDiskNameFromTemplate(diskParams.DiskNameTemplate, azureutils.NewDiskNameTemplateValues(pvcNamespace, pvcName, name)); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
 	if diskParams.DiskName == "" {
 		diskParams.DiskName = name
 	}
 	diskParams.DiskName = azureutils.CreateValidDiskName(diskParams.DiskName)
 
-	if diskParams.ResourceGroup == "" {
-		diskParams.ResourceGroup = d.cloud.ResourceGroup
-	}
-
 	// normalize values
 	skuName, err := azureutils.NormalizeStorageAccountType(diskParams.AccountType, d.cloud.Config.Cloud, d.cloud.Config.DisableAzureStackCloud)
 	if err != nil {
@@ -124,6 +177,31 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	if err := azureutils.ValidateDiskAccessID(diskParams.DiskAccessID, networkAccessPolicy); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	publicNetworkAccess, err := azureutils.NormalizePublicNetworkAccess(diskParams.PublicNetworkAccess)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := azureutils.ValidatePerformanceTier(diskParams.Tier, skuName); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := azureutils.ValidateDiskBursting(diskParams.EnableBursting, skuName, requestGiB); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := azureutils.ValidateLogicalSectorSize(diskParams.LogicalSectorSize, skuName); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := azureutils.ValidateWriteAccelerator(diskParams.WriteAcceleratorEnabled, skuName, diskParams.CachingMode); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	selectedAvailabilityZone := azureutils.PickAvailabilityZone(req.GetAccessibilityRequirements(), d.cloud.Location, topologyKey)
 
 	if d.enableDiskCapacityCheck {
@@ -140,6 +218,22 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 	if strings.EqualFold(diskParams.WriteAcceleratorEnabled, consts.TrueValue) {
 		diskParams.Tags[azure.WriteAcceleratorEnabled] = consts.TrueValue
 	}
+	if diskParams.TagsFromLabels != "" {
+		pvcName, pvcNamespace := diskParams.Tags[consts.PvcNameTag], diskParams.Tags[consts.PvcNamespaceTag]
+		if pvcName == "" || pvcNamespace == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s requires PVC name and namespace, enable --extra-create-metadata in csi-provisioner", consts.TagsFromLabelsField)
+		}
+		labelTags, err := getTagsFromLabels(ctx, d.cloud.KubeClient, diskParams.TagsFromLabels, pvcName, pvcNamespace)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get tags from labels: %v", err)
+		}
+		// tags specified in the StorageClass take precedence over tags from labels
+		for k, v := range labelTags {
+			if _, ok := diskParams.Tags[k]; !ok {
+				diskParams.Tags[k] = v
+			}
+		}
+	}
 	sourceID := ""
 	sourceType := ""
 	content := req.GetVolumeContentSource()
@@ -154,6 +248,20 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 					},
 				},
 			}
+			if azureutils.IsARMResourceID(sourceID) {
+				snapshotGiB, err := d.getSnapshotSize(ctx, sourceID)
+				if err != nil {
+					return nil, err
+				}
+				if snapshotGiB != nil {
+					if *snapshotGiB > int32(requestGiB) {
+						return nil, status.Errorf(codes.InvalidArgument, "requested size(%d GiB) is smaller than the size(%d GiB) of source snapshot(%s)", requestGiB, *snapshotGiB, sourceID)
+					}
+					if *snapshotGiB < int32(requestGiB) {
+						diskParams.VolumeContext[consts.ResizeRequired] = strconv.FormatBool(true)
+					}
+				}
+			}
 		} else {
 			sourceID = content.GetVolume().GetVolumeId()
 			sourceType = consts.SourceVolume
@@ -165,8 +273,13 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 				},
 			}
 
+			// the source disk may live in a different resource group or subscription than the new disk
 			subsID := azureutils.GetSubscriptionIDFromURI(sourceID)
-			if sourceGiB, _ := d.GetSourceDiskSize(ctx, subsID, diskParams.ResourceGroup, path.Base(sourceID), 0, consts.SourceDiskSearchMaxDepth); sourceGiB != nil && *sourceGiB < int32(requestGiB) {
+			sourceResourceGroup, err := azureutils.GetResourceGroupFromURI(sourceID)
+			if err != nil {
+				sourceResourceGroup = diskParams.ResourceGroup
+			}
+			if sourceGiB, _ := d.GetSourceDiskSize(ctx, subsID, sourceResourceGroup, path.Base(sourceID), 0, consts.SourceDiskSearchMaxDepth); sourceGiB != nil && *sourceGiB < int32(requestGiB) {
 				diskParams.VolumeContext[consts.ResizeRequired] = strconv.FormatBool(true)
 			}
 		}
@@ -200,17 +313,52 @@ func (d *DriverV2) CreateVolume(ctx context.Context, req *csi.CreateVolumeReques
 
 	var diskURI string
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_create_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_create_volume")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI)
+		auditOp.Finish(isOperationSucceeded, consts.VolumeID, diskURI, consts.PvcNamespaceKey, diskParams.Tags[consts.PvcNamespaceTag], consts.PvcNameKey, diskParams.Tags[consts.PvcNameTag])
 	}()
 
+	if sourceType == consts.SourceVolume && azureutils.IsARMResourceID(sourceID) {
+		attached, err := isDiskAttached(ctx, d.cloud, sourceID)
+		if err != nil {
+			return nil, azureutils.NewStatusFromAzureError(err, fmt.Sprintf("failed to get source disk(%s)", sourceID))
+		}
+		if attached {
+			// cloning an attached disk goes through an intermediate snapshot which is removed once the clone is created
+			snapshotID, err := createCloneSourceSnapshot(ctx, d.cloud, d.auditLogger, diskParams, sourceID)
+			if err != nil {
+				return nil, err
+			}
+			defer deleteCloneSourceSnapshot(d.cloud, d.auditLogger, snapshotID)
+			volumeOptions.SourceResourceID = snapshotID
+			volumeOptions.SourceType = consts.SourceSnapshot
+		}
+	}
+
 	diskURI, err = d.cloud.CreateManagedDisk(ctx, volumeOptions)
 	if err != nil {
-		if strings.Contains(err.Error(), consts.NotFound) {
-			return nil, status.Error(codes.NotFound, err.Error())
+		return nil, azureutils.NewStatusFromAzureError(err, "")
+	}
+
+	// performance tier and public network access cannot be set by CreateManagedDisk, update them after creation
+	if diskParams.Tier != "" || publicNetworkAccess != "" {
+		klog.V(2).Infof("begin to update azure disk(%s) with performance tier(%s) publicNetworkAccess(%s)", diskURI, diskParams.Tier, publicNetworkAccess)
+		diskUpdate := compute.DiskUpdate{
+			DiskUpdateProperties: &compute.DiskUpdateProperties{
+				PublicNetworkAccess: publicNetworkAccess,
+			},
+		}
+		if diskParams.Tier != "" {
+			diskUpdate.Tier = &diskParams.Tier
+		}
+		updateAuditOp := d.auditLogger.StartOperation("controller_update_disk")
+		err := updateDisk(ctx, d.cloud, diskURI, diskUpdate)
+		updateAuditOp.Finish(err == nil, consts.VolumeID, diskURI)
+		if err != nil {
+			return nil, azureutils.NewStatusFromAzureError(err, fmt.Sprintf("failed to update performance tier(%s) publicNetworkAccess(%s) of disk(%s)", diskParams.Tier, publicNetworkAccess, diskURI))
 		}
-		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
 	isOperationSucceeded = true
@@ -254,9 +402,11 @@ func (d *DriverV2) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeReques
 	defer d.volumeLocks.Release(volumeID)
 
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_delete_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_delete_volume")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI)
+		auditOp.Finish(isOperationSucceeded, consts.VolumeID, diskURI)
 	}()
 
 	klog.V(2).Infof("deleting azure disk(%s)", diskURI)
@@ -310,9 +460,11 @@ func (d *DriverV2) ControllerPublishVolume(ctx context.Context, req *csi.Control
 	}
 
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_publish_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_publish_volume")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI, consts.Node, string(nodeName))
+		auditOp.Finish(isOperationSucceeded, consts.VolumeID, diskURI, consts.Node, string(nodeName))
 	}()
 
 	lun, vmState, err := d.cloud.GetDiskLun(diskName, diskURI, nodeName)
@@ -341,6 +493,13 @@ func (d *DriverV2) ControllerPublishVolume(ctx context.Context, req *csi.Control
 		if cachingMode, err = azureutils.GetCachingMode(volumeContext); err != nil {
 			return nil, status.Errorf(codes.Internal, err.Error())
 		}
+		if disk != nil && strings.EqualFold(to.String(disk.Tags[azure.WriteAcceleratorEnabled]), consts.TrueValue) {
+			if instanceType, err := d.cloud.InstanceType(ctx, nodeName); err != nil {
+				klog.Warningf("failed to get instance type of node %s: %v", nodeName, err)
+			} else if instanceType != "" && !azureutils.IsWriteAcceleratorSupportedVMSize(instanceType) {
+				return nil, status.Errorf(codes.FailedPrecondition, "volume %s has write accelerator enabled, which is not supported on node %s of size %s", diskURI, nodeName, instanceType)
+			}
+		}
 		klog.V(2).Infof("Trying to attach volume %s to node %s", diskURI, nodeName)
 
 		lun, err = d.cloud.AttachDisk(ctx, true, diskName, diskURI, nodeName, cachingMode, disk)
@@ -357,7 +516,7 @@ func (d *DriverV2) ControllerPublishVolume(ctx context.Context, req *csi.Control
 			}
 			if err != nil {
 				klog.Errorf("Attach volume %s to instance %s failed with %v", diskURI, nodeName, err)
-				return nil, status.Errorf(codes.Internal, "Attach volume %s to instance %s failed with %v", diskURI, nodeName, err)
+				return nil, azureutils.NewStatusFromAzureError(err, fmt.Sprintf("Attach volume %s to instance %s failed", diskURI, nodeName))
 			}
 		}
 		klog.V(2).Infof("attach volume %s to node %s successfully", diskURI, nodeName)
@@ -393,9 +552,11 @@ func (d *DriverV2) ControllerUnpublishVolume(ctx context.Context, req *csi.Contr
 	}
 
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_unpublish_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_unpublish_volume")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI, consts.Node, string(nodeName))
+		auditOp.Finish(isOperationSucceeded, consts.VolumeID, diskURI, consts.Node, string(nodeName))
 	}()
 
 	klog.V(2).Infof("Trying to detach volume %s from node %s", diskURI, nodeID)
@@ -681,9 +842,11 @@ func (d *DriverV2) ControllerExpandVolume(ctx context.Context, req *csi.Controll
 	}
 
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_expand_volume", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_expand_volume")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.VolumeID, diskURI)
+		auditOp.Finish(isOperationSucceeded, consts.VolumeID, diskURI)
 	}()
 
 	subsID := azureutils.GetSubscriptionIDFromURI(diskURI)
@@ -696,10 +859,16 @@ func (d *DriverV2) ControllerExpandVolume(ctx context.Context, req *csi.Controll
 	}
 	oldSize := *resource.NewQuantity(int64(*result.DiskProperties.DiskSizeGB), resource.BinarySI)
 
-	klog.V(2).Infof("begin to expand azure disk(%s) with new size(%d)", diskURI, requestSize.Value())
-	newSize, err := d.cloud.ResizeDisk(ctx, diskURI, oldSize, requestSize, d.enableDiskOnlineResize)
+	supportOnlineResize := d.enableDiskOnlineResize
+	if supportOnlineResize && !azureutils.IsDiskOnlineResizeSupported(&result, int32(volumehelper.RoundUpGiB(capacityBytes))) {
+		klog.V(2).Infof("online resize is not supported for disk(%s), disk needs to be detached before resize", diskURI)
+		supportOnlineResize = false
+	}
+
+	klog.V(2).Infof("begin to expand azure disk(%s) with new size(%d), online resize: %t", diskURI, requestSize.Value(), supportOnlineResize)
+	newSize, err := d.cloud.ResizeDisk(ctx, diskURI, oldSize, requestSize, supportOnlineResize)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to resize disk(%s) with error(%v)", diskURI, err)
+		return nil, azureutils.NewStatusFromAzureError(err, fmt.Sprintf("failed to resize disk(%s)", diskURI))
 	}
 
 	currentSize, ok := newSize.AsInt64()
@@ -787,9 +956,11 @@ func (d *DriverV2) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRe
 		Tags:     tags,
 	}
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_create_snapshot", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_create_snapshot")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.SourceResourceID, sourceVolumeID, consts.SnapshotName, snapshotName)
+		auditOp.Finish(isOperationSucceeded, consts.SourceResourceID, sourceVolumeID, consts.SnapshotName, snapshotName)
 	}()
 
 	klog.V(2).Infof("begin to create snapshot(%s, incremental: %v) under rg(%s)", snapshotName, incremental, resourceGroup)
@@ -799,7 +970,7 @@ func (d *DriverV2) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRe
 		}
 
 		azureutils.SleepIfThrottled(rerr.Error(), azureconstants.SnapshotOpThrottlingSleepSec)
-		return nil, status.Error(codes.Internal, fmt.Sprintf("create snapshot error: %v", rerr.Error()))
+		return nil, azureutils.NewStatusFromAzureError(rerr.Error(), "create snapshot error")
 	}
 	klog.V(2).Infof("create snapshot(%s) under rg(%s) successfully", snapshotName, resourceGroup)
 
@@ -835,9 +1006,11 @@ func (d *DriverV2) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRe
 	}
 
 	mc := metrics.NewMetricContext(consts.AzureDiskCSIDriverName, "controller_delete_snapshot", d.cloud.ResourceGroup, d.cloud.SubscriptionID, d.Name)
+	auditOp := d.auditLogger.StartOperation("controller_delete_snapshot")
 	isOperationSucceeded := false
 	defer func() {
 		mc.ObserveOperationWithResult(isOperationSucceeded, consts.SnapshotID, snapshotName)
+		auditOp.Finish(isOperationSucceeded, consts.SnapshotID, snapshotName)
 	}()
 
 	klog.V(2).Infof("begin to delete snapshot(%s) under rg(%s)", snapshotName, resourceGroup)
@@ -917,8 +1090,9 @@ func (d *DriverV2) GetSourceDiskSize(ctx context.Context, subsID, resourceGroup,
 		klog.V(2).Infof("Clone source disk has a parent source")
 		sourceResourceID := *result.DiskProperties.CreationData.SourceResourceID
 		parentResourceGroup, _ := azureutils.GetResourceGroupFromURI(sourceResourceID)
+		parentSubsID := azureutils.GetSubscriptionIDFromURI(sourceResourceID)
 		parentDiskName := path.Base(sourceResourceID)
-		return d.GetSourceDiskSize(ctx, subsID, parentResourceGroup, parentDiskName, curDepth+1, maxDepth)
+		return d.GetSourceDiskSize(ctx, parentSubsID, parentResourceGroup, parentDiskName, curDepth+1, maxDepth)
 	}
 
 	if (*result.DiskProperties).DiskSizeGB == nil {
@@ -927,6 +1101,25 @@ func (d *DriverV2) GetSourceDiskSize(ctx context.Context, subsID, resourceGroup,
 	return (*result.DiskProperties).DiskSizeGB, nil
 }
 
+// getSnapshotSize returns the disk size of the snapshot identified by snapshotID
+func (d *DriverV2) getSnapshotSize(ctx context.Context, snapshotID string) (*int32, error) {
+	snapshotName, resourceGroup, subsID, err := d.getSnapshotInfo(snapshotID)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	snapshot, rerr := d.cloud.SnapshotsClient.Get(ctx, subsID, resourceGroup, snapshotName)
+	if rerr != nil {
+		if rerr.IsNotFound() {
+			return nil, status.Errorf(codes.NotFound, "source snapshot(%s) not found", snapshotID)
+		}
+		return nil, status.Errorf(codes.Internal, "get snapshot %s from rg(%s) error: %v", snapshotName, resourceGroup, rerr.Error())
+	}
+	if snapshot.SnapshotProperties == nil {
+		return nil, nil
+	}
+	return snapshot.SnapshotProperties.DiskSizeGB, nil
+}
+
 // The format of snapshot id is /subscriptions/xxx/resourceGroups/xxx/providers/Microsoft.Compute/snapshots/snapshot-xxx-xxx.
 func (d *DriverV2) getSnapshotInfo(snapshotID string) (snapshotName, resourceGroup, subsID string, err error) {
 	if snapshotName, err = azureutils.GetSnapshotNameFromURI(snapshotID); err != nil {
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"time"
 
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/features"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/optimization"
 	volumehelper "sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
@@ -40,6 +41,7 @@ import (
 	"k8s.io/klog/v2"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureutils"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/crypt"
 )
 
 const (
@@ -147,15 +149,28 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 		// respect "fstype" setting in storage class parameters
 		fstype = volContextFSType
 	}
+	formatOptions := azureutils.GetFsParams(req.GetVolumeContext())
 
 	// If partition is specified, should mount it only instead of the entire disk.
 	if partition, ok := req.GetVolumeContext()[consts.VolumeAttributePartition]; ok {
 		source = source + "-part" + partition
 	}
 
+	if azureutils.IsLuksEncrypted(req.GetVolumeContext()) {
+		passphrase := req.GetSecrets()[consts.LuksPassphraseKey]
+		if passphrase == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s must be provided in node stage secrets for LUKS encrypted volume %s", consts.LuksPassphraseKey, diskURI)
+		}
+		luksSource, err := openLuksDevice(source, diskURI, passphrase, d.mounter)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "could not open LUKS device on %s(lun: %s): %v", source, lun, err)
+		}
+		source = luksSource
+	}
+
 	// FormatAndMount will format only if needed
-	klog.V(2).Infof("NodeStageVolume: formatting %s and mounting at %s with mount options(%s)", source, target, options)
-	if err := d.formatAndMount(source, target, fstype, options); err != nil {
+	klog.V(2).Infof("NodeStageVolume: formatting %s and mounting at %s with format options(%s) mount options(%s)", source, target, formatOptions, options)
+	if err := d.formatAndMount(source, target, fstype, options, formatOptions); err != nil {
 		return nil, status.Errorf(codes.Internal, "could not format %s(lun: %s), and mount it at %s", source, lun, target)
 	}
 	klog.V(2).Infof("NodeStageVolume: format %s and mounting at %s successfully.", source, target)
@@ -205,6 +220,10 @@ func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolu
 	}
 	klog.V(2).Infof("NodeUnstageVolume: unmount %s successfully", stagingTargetPath)
 
+	if err := closeLuksDevice(volumeID, d.mounter); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to close LUKS device of volume %q: %v", volumeID, err)
+	}
+
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
@@ -422,9 +441,14 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 	}
 
 	volUsage, err := GetVolumeStats(ctx, d.mounter, req.VolumePath, d.hostUtil)
-	return &csi.NodeGetVolumeStatsResponse{
-		Usage: volUsage,
-	}, err
+	if err != nil {
+		return &csi.NodeGetVolumeStatsResponse{Usage: volUsage}, err
+	}
+	resp := &csi.NodeGetVolumeStatsResponse{Usage: volUsage}
+	if features.DefaultFeatureGate.Enabled(features.VolumeCondition) {
+		resp.VolumeCondition = getVolumeCondition(req.VolumePath, d.ioHandler)
+	}
+	return resp, nil
 }
 
 // NodeExpandVolume node expand volume
@@ -474,14 +498,19 @@ func (d *Driver) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolume
 		return nil, status.Errorf(codes.NotFound, err.Error())
 	}
 
-	if d.enableDiskOnlineResize {
+	if mapperName, ok := crypt.GetMapperName(devicePath); ok {
+		klog.V(2).Infof("NodeExpandVolume begin to resize LUKS device %s on volume(%s)", devicePath, volumeID)
+		if err := resizeLuksDevice(mapperName, d.enableDiskOnlineResize, d.ioHandler, d.mounter); err != nil {
+			return nil, status.Errorf(codes.Internal, "could not resize LUKS device %q of volume %q: %v", devicePath, volumeID, err)
+		}
+	} else if d.enableDiskOnlineResize {
 		klog.V(2).Info("NodeExpandVolume begin to rescan device %s on volume(%s)", devicePath, volumeID)
 		if err := rescanVolume(d.ioHandler, devicePath); err != nil {
 			klog.Errorf("NodeExpandVolume rescanVolume failed with error: %v", err)
 		}
 	}
 
-	if err := resizeVolume(devicePath, volumePath, d.mounter); err != nil {
+	if err := resizeVolumeWithRetry(ctx, devicePath, volumePath, d.mounter); err != nil {
 		return nil, status.Errorf(codes.Internal, "could not resize volume %q (%q):  %v", volumeID, devicePath, err)
 	}
 
@@ -563,8 +592,8 @@ func (d *Driver) ensureMountPoint(target string) (bool, error) {
 	return !notMnt, nil
 }
 
-func (d *Driver) formatAndMount(source, target, fstype string, options []string) error {
-	return formatAndMount(source, target, fstype, options, d.mounter)
+func (d *Driver) formatAndMount(source, target, fstype string, options, formatOptions []string) error {
+	return formatAndMount(source, target, fstype, options, formatOptions, d.mounter)
 }
 
 func (d *Driver) getDevicePathWithLUN(lunStr string) (string, error) {
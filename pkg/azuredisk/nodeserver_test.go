@@ -507,6 +507,18 @@ func TestNodeStageVolume(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			desc:          "LUKS passphrase not provided",
+			skipOnDarwin:  true,
+			skipOnWindows: true,
+			req: csi.NodeStageVolumeRequest{VolumeId: "vol_1", StagingTargetPath: sourceTest,
+				VolumeCapability: &csi.VolumeCapability{AccessMode: &volumeCap,
+					AccessType: stdVolCap},
+				PublishContext: publishContext,
+				VolumeContext:  map[string]string{consts.EncryptedField: consts.LuksEncryption},
+			},
+			expectedErr: status.Error(codes.InvalidArgument, "passphrase must be provided in node stage secrets for LUKS encrypted volume vol_1"),
+		},
 		{
 			desc:          "Successfully staged with performance optimizations",
 			skipOnDarwin:  true,
@@ -938,6 +950,9 @@ func TestNodeExpandVolume(t *testing.T) {
 	findmntAction := func() ([]byte, []byte, error) {
 		return []byte("test"), []byte{}, nil
 	}
+	findmntSubpathAction := func() ([]byte, []byte, error) {
+		return []byte("test[/subpath]"), []byte{}, nil
+	}
 	blkidAction := func() ([]byte, []byte, error) {
 		return []byte("DEVICE=test\nTYPE=ext4"), []byte{}, nil
 	}
@@ -1048,6 +1063,18 @@ func TestNodeExpandVolume(t *testing.T) {
 			skipOnDarwin:  true, // ResizeFs not supported on Darwin
 			outputScripts: []testingexec.FakeAction{findmntAction, blkidAction, resize2fsAction, blockdevAction},
 		},
+		{
+			desc: "Successfully expanded bind mounted subpath",
+			req: csi.NodeExpandVolumeRequest{
+				CapacityRange:     stdCapacityRange,
+				VolumePath:        targetTest,
+				VolumeId:          "test",
+				StagingTargetPath: "test",
+			},
+			skipOnWindows: true,
+			skipOnDarwin:  true, // ResizeFs not supported on Darwin
+			outputScripts: []testingexec.FakeAction{findmntSubpathAction, blkidAction, resize2fsAction, blockdevAction},
+		},
 		{
 			desc: "Block volume expansion",
 			req: csi.NodeExpandVolumeRequest{
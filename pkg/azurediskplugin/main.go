@@ -27,13 +27,16 @@ import (
 
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/azuredisk"
 
+	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
+	"sigs.k8s.io/azuredisk-csi-driver/pkg/features"
 )
 
 func init() {
 	klog.InitFlags(nil)
+	flag.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe feature gates for alpha/beta features. Options are:\n"+strings.Join(features.DefaultMutableFeatureGate.KnownFeatures(), "\n"))
 }
 
 var (
@@ -61,6 +64,10 @@ var (
 	enableListSnapshots        = flag.Bool("enable-list-snapshots", false, "boolean flag to enable ListSnapshots on controller")
 	enableDiskCapacityCheck    = flag.Bool("enable-disk-capacity-check", false, "boolean flag to enable volume capacity check in CreateVolume")
 	vmssCacheTTLInSeconds      = flag.Int64("vmss-cache-ttl-seconds", -1, "vmss cache TTL in seconds (600 by default)")
+	diskCacheTTLInSeconds      = flag.Int64("disk-cache-ttl-seconds", 0, "managed disk GET cache TTL in seconds, disk cache is disabled if it's 0")
+	featureGates               = map[string]bool{}
+	auditLogPath               = flag.String("audit-log-path", "", "path of the file where Azure disk and snapshot mutations issued by the controller are recorded as JSON lines, auditing is disabled if it's empty")
+	allowedPVCAnnotationParams = flag.String("allowed-pvc-annotation-params", "", "comma separated StorageClass parameters that can be overridden by disk.csi.azure.com/<parameter> PVC annotations, e.g. cachingMode,tags")
 )
 
 func main() {
@@ -79,6 +86,10 @@ func main() {
 		klog.Warning("nodeid is empty")
 	}
 
+	if err := features.DefaultMutableFeatureGate.SetFromMap(featureGates); err != nil {
+		klog.Fatalf("failed to set feature gates: %v", err)
+	}
+	features.RecordFeatureGateMetrics(features.DefaultFeatureGate)
 	exportMetrics()
 	handle()
 	os.Exit(0)
@@ -104,7 +115,10 @@ func handle() {
 		GetNodeInfoFromLabels:      *getNodeInfoFromLabels,
 		EnableDiskCapacityCheck:    *enableDiskCapacityCheck,
 		VMSSCacheTTLInSeconds:      *vmssCacheTTLInSeconds,
+		DiskCacheTTLInSeconds:      *diskCacheTTLInSeconds,
 		VMType:                     *vmType,
+		AllowedPVCAnnotationParams: *allowedPVCAnnotationParams,
+		AuditLogPath:               *auditLogPath,
 	}
 	driver := azuredisk.NewDriver(&driverOptions)
 	if driver == nil {
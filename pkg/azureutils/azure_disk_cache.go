@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+const (
+	diskCacheHit  = "hit"
+	diskCacheMiss = "miss"
+)
+
+var (
+	diskCacheRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "azuredisk_csi_driver",
+			Name:           "disk_cache_requests_total",
+			Help:           "Number of managed disk GET requests served by the disk cache, partitioned by hit or miss.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+	registerDiskCacheMetrics sync.Once
+)
+
+type diskCacheEntry struct {
+	disk    compute.Disk
+	expires time.Time
+}
+
+// DiskCache is a TTL based cache of managed disk GET results keyed by disk URI.
+// Entries must be invalidated after any write to the disk.
+type DiskCache struct {
+	ttl   time.Duration
+	lock  sync.Mutex
+	disks map[string]diskCacheEntry
+	// generation is incremented on every Invalidate, invalidated records the generation at which each disk was last
+	// invalidated so that a GET started before an Invalidate does not store a stale disk after it.
+	// invalidated is only needed while GETs are in flight and is cleared when there are none.
+	generation  uint64
+	invalidated map[string]uint64
+	inFlight    int
+}
+
+// NewDiskCache returns a DiskCache that keeps disks for ttl. A ttl of zero or less disables caching.
+func NewDiskCache(ttl time.Duration) *DiskCache {
+	registerDiskCacheMetrics.Do(func() {
+		legacyregistry.MustRegister(diskCacheRequests)
+	})
+	return &DiskCache{
+		ttl:         ttl,
+		disks:       make(map[string]diskCacheEntry),
+		invalidated: make(map[string]uint64),
+	}
+}
+
+// Get returns the disk identified by diskURI, calling getter if it is not cached or its entry has expired.
+func (c *DiskCache) Get(diskURI string, getter func() (compute.Disk, *retry.Error)) (compute.Disk, *retry.Error) {
+	if c == nil || c.ttl <= 0 {
+		return getter()
+	}
+
+	key := strings.ToLower(diskURI)
+	c.lock.Lock()
+	entry, ok := c.disks[key]
+	if ok && time.Now().Before(entry.expires) {
+		c.lock.Unlock()
+		diskCacheRequests.WithLabelValues(diskCacheHit).Inc()
+		return entry.disk, nil
+	}
+	generation := c.generation
+	c.inFlight++
+	c.lock.Unlock()
+
+	diskCacheRequests.WithLabelValues(diskCacheMiss).Inc()
+	disk, rerr := getter()
+
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.inFlight--
+	stale := c.invalidated[key] > generation
+	if c.inFlight == 0 && len(c.invalidated) > 0 {
+		c.invalidated = make(map[string]uint64)
+	}
+	if rerr != nil {
+		return disk, rerr
+	}
+	if stale {
+		// the disk was invalidated while the GET was in flight, the result may predate the write
+		return disk, nil
+	}
+	// drop expired entries so that disks which are no longer read do not stay in the cache forever
+	for k, e := range c.disks {
+		if !now.Before(e.expires) {
+			delete(c.disks, k)
+		}
+	}
+	c.disks[key] = diskCacheEntry{disk: disk, expires: now.Add(c.ttl)}
+	return disk, nil
+}
+
+// Len returns the number of disks in the cache, including expired entries not yet evicted.
+func (c *DiskCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.disks)
+}
+
+// Invalidate removes the disk identified by diskURI from the cache.
+func (c *DiskCache) Invalidate(diskURI string) {
+	if c == nil {
+		return
+	}
+	key := strings.ToLower(diskURI)
+	c.lock.Lock()
+	delete(c.disks, key)
+	if c.inFlight > 0 {
+		c.generation++
+		c.invalidated[key] = c.generation
+	}
+	c.lock.Unlock()
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+func TestDiskCache(t *testing.T) {
+	diskURI := "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/disk"
+	calls := 0
+	getter := func() (compute.Disk, *retry.Error) {
+		calls++
+		return compute.Disk{Name: to.StringPtr("disk")}, nil
+	}
+	failingGetter := func() (compute.Disk, *retry.Error) {
+		calls++
+		return compute.Disk{}, &retry.Error{RawError: fmt.Errorf("test")}
+	}
+
+	tests := []struct {
+		description   string
+		ttl           time.Duration
+		testFunc      func(c *DiskCache)
+		expectedCalls int
+	}{
+		{
+			description: "cache disabled",
+			ttl:         0,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, getter)
+				_, _ = c.Get(diskURI, getter)
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "cache hit",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, getter)
+				disk, rerr := c.Get(diskURI, getter)
+				assert.Nil(t, rerr)
+				assert.Equal(t, "disk", to.String(disk.Name))
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "disk URI is case insensitive",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, getter)
+				_, _ = c.Get("/subscriptions/subs/resourcegroups/RG/providers/microsoft.compute/disks/disk", getter)
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "cache invalidated",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, getter)
+				c.Invalidate(diskURI)
+				_, _ = c.Get(diskURI, getter)
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "cache entry expired",
+			ttl:         time.Millisecond,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, getter)
+				time.Sleep(2 * time.Millisecond)
+				_, _ = c.Get(diskURI, getter)
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "disk invalidated during GET is not cached",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, func() (compute.Disk, *retry.Error) {
+					calls++
+					c.Invalidate(diskURI)
+					return compute.Disk{Name: to.StringPtr("stale")}, nil
+				})
+				assert.Empty(t, c.invalidated, "invalidations should be dropped once no GET is in flight")
+				disk, _ := c.Get(diskURI, getter)
+				assert.Equal(t, "disk", to.String(disk.Name))
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "other disk invalidated during GET",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, _ = c.Get(diskURI, func() (compute.Disk, *retry.Error) {
+					calls++
+					c.Invalidate("/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/other")
+					return compute.Disk{Name: to.StringPtr("disk")}, nil
+				})
+				_, _ = c.Get(diskURI, getter)
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "errors are not cached",
+			ttl:         time.Minute,
+			testFunc: func(c *DiskCache) {
+				_, rerr := c.Get(diskURI, failingGetter)
+				assert.NotNil(t, rerr)
+				_, _ = c.Get(diskURI, getter)
+			},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, test := range tests {
+		calls = 0
+		test.testFunc(NewDiskCache(test.ttl))
+		assert.Equal(t, test.expectedCalls, calls, test.description)
+	}
+
+	c := NewDiskCache(time.Millisecond)
+	_, _ = c.Get(diskURI, getter)
+	time.Sleep(2 * time.Millisecond)
+	_, _ = c.Get("/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/other", getter)
+	assert.Equal(t, 1, c.Len(), "expired entries should be evicted")
+
+	var nilCache *DiskCache
+	nilCache.Invalidate(diskURI)
+	_, _ = nilCache.Get(diskURI, getter)
+}
@@ -17,12 +17,16 @@ limitations under the License.
 package azureutils
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 	"unicode"
 
@@ -40,12 +44,12 @@ import (
 	clientset "k8s.io/client-go/kubernetes"
 	api "k8s.io/kubernetes/pkg/apis/core"
 	volumeUtil "k8s.io/kubernetes/pkg/volume/util"
-	"sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/optimization"
 	"sigs.k8s.io/azuredisk-csi-driver/pkg/util"
 	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
 	azure "sigs.k8s.io/cloud-provider-azure/pkg/provider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
 const (
@@ -59,19 +63,40 @@ const (
 	diskNameMinLength         = 1
 	diskNameMaxLength         = 80
 	diskNameGenerateMaxLength = 76 // maxLength = 80 - (4 for ".vhd") = 76
+	// length of the {{.Random}} value available to diskNameTemplate
+	diskNameRandomLength = 8
+	// on-demand bursting is only supported on premium SSDs larger than 512 GiB
+	// see https://docs.microsoft.com/en-us/azure/virtual-machines/disk-bursting#on-demand-bursting
+	burstingMinDiskSizeGiB = 512
+	// disks of 4 TiB or less cannot be expanded beyond 4 TiB while attached
+	// see https://docs.microsoft.com/en-us/azure/virtual-machines/linux/expand-disks#expand-without-downtime
+	onlineResizeMaxDiskSizeGiB = 4096
 )
 
 var (
+	diskAccessIDRE = regexp.MustCompile(`(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft.Compute/diskAccesses/[^/]+$`)
 	// see https://docs.microsoft.com/en-us/rest/api/compute/disks/createorupdate#create-a-managed-disk-by-copying-a-snapshot.
 	diskSnapshotPath        = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/snapshots/%s"
 	diskSnapshotPathRE      = regexp.MustCompile(`(?i).*/subscriptions/(?:.*)/resourceGroups/(?:.*)/providers/Microsoft.Compute/snapshots/(.+)`)
 	diskURISupportedManaged = []string{"/subscriptions/{sub-id}/resourcegroups/{group-name}/providers/microsoft.compute/disks/{disk-id}"}
 	lunPathRE               = regexp.MustCompile(`/dev(?:.*)/disk/azure/scsi(?:.*)/lun(.+)`)
+	performanceTierRE       = regexp.MustCompile(`^P[0-9]+$`)
 	supportedCachingModes   = sets.NewString(
 		string(api.AzureDataDiskCachingNone),
 		string(api.AzureDataDiskCachingReadOnly),
 		string(api.AzureDataDiskCachingReadWrite),
 	)
+	// see https://docs.microsoft.com/en-us/azure/virtual-machines/disks-enable-ultra-ssd
+	supportedLogicalSectorSizes = sets.NewInt(512, 4096)
+	// fsSpecificMountOptions are the mount options that are only understood by one filesystem family.
+	// quota, usrquota, grpquota and prjquota are understood by both ext4 and xfs, while uquota, gquota and pquota are xfs only
+	fsSpecificMountOptions = map[string]sets.String{
+		"btrfs": sets.NewString("autodefrag", "compress", "compress-force", "degraded", "nodatacow", "nodatasum", "space_cache", "ssd", "subvol", "subvolid"),
+		"ext":   sets.NewString("data", "delalloc", "dioread_nolock", "errors", "grpjquota", "jqfmt", "journal_checksum", "nodelalloc", "nojournal_checksum", "stripe", "usrjquota"),
+		"xfs":   sets.NewString("allocsize", "attr2", "gquota", "inode64", "largeio", "logbsize", "logbufs", "noattr2", "nouuid", "pquota", "swalloc", "uquota"),
+	}
+	// see https://docs.microsoft.com/en-us/azure/azure-resource-manager/management/tag-resources#limitations
+	tagNameReplacer = strings.NewReplacer("<", "_", ">", "_", "%", "_", "&", "_", "\\", "_", "?", "_", "/", "_")
 
 	// volumeCaps represents how the volume could be accessed.
 	volumeCaps = []csi.VolumeCapability_AccessMode{
@@ -108,8 +133,11 @@ type ManagedDiskParameters struct {
 	DiskIOPSReadWrite       string
 	DiskMBPSReadWrite       string
 	DiskName                string
+	DiskNameTemplate        string
 	EnableAsyncAttach       *bool
 	EnableBursting          *bool
+	Encrypted               string
+	FsParams                string
 	FsType                  string
 	Incremental             bool
 	Location                string
@@ -117,9 +145,12 @@ type ManagedDiskParameters struct {
 	MaxShares               int
 	NetworkAccessPolicy     string
 	PerfProfile             string
+	PublicNetworkAccess     string
 	SubscriptionID          string
 	ResourceGroup           string
 	Tags                    map[string]string
+	TagsFromLabels          string
+	Tier                    string
 	UserAgent               string
 	VolumeContext           map[string]string
 	WriteAcceleratorEnabled string
@@ -255,6 +286,62 @@ func GetKubeClient(kubeconfig string) (*clientset.Clientset, error) {
 	return clientset.NewForConfig(config)
 }
 
+// GetTagsFromLabels returns the tags for the comma separated label keys in tagsFromLabels that are found in labelSets.
+// Later label sets take precedence over earlier ones. Characters not allowed in tag names are replaced with '_'.
+func GetTagsFromLabels(tagsFromLabels string, labelSets ...map[string]string) map[string]string {
+	tags := make(map[string]string)
+	for _, key := range strings.Split(tagsFromLabels, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		for _, labels := range labelSets {
+			if v, ok := labels[key]; ok {
+				tags[tagNameReplacer.Replace(key)] = v
+			}
+		}
+	}
+	return tags
+}
+
+// GetAllowedPVCAnnotationParameters returns the lower-cased StorageClass parameter names in the comma separated allowedParameters
+func GetAllowedPVCAnnotationParameters(allowedParameters string) sets.String {
+	allowed := sets.NewString()
+	for _, param := range strings.Split(allowedParameters, ",") {
+		if param = strings.ToLower(strings.TrimSpace(param)); param != "" {
+			allowed.Insert(param)
+		}
+	}
+	return allowed
+}
+
+// OverrideParametersFromPVCAnnotations returns a copy of parameters with the values of PVC annotations prefixed with
+// "disk.csi.azure.com/" applied on top. An error is returned if an annotation names a parameter not in allowedParameters.
+func OverrideParametersFromPVCAnnotations(parameters, annotations map[string]string, allowedParameters sets.String) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, consts.PvcAnnotationParameterPrefix) {
+			continue
+		}
+		param := strings.ToLower(strings.TrimPrefix(k, consts.PvcAnnotationParameterPrefix))
+		if !allowedParameters.Has(param) {
+			return nil, fmt.Errorf("PVC annotation %s is not allowed, allowed parameters: %v", k, allowedParameters.List())
+		}
+		overrides[param] = v
+	}
+
+	result := make(map[string]string, len(parameters)+len(overrides))
+	for k, v := range parameters {
+		if _, ok := overrides[strings.ToLower(k)]; !ok {
+			result[k] = v
+		}
+	}
+	for k, v := range overrides {
+		result[k] = v
+	}
+	return result, nil
+}
+
 // GetDiskLUN : deviceInfo could be a LUN number or a device path, e.g. /dev/disk/azure/scsi1/lun2
 func GetDiskLUN(deviceInfo string) (int32, error) {
 	var diskLUN string
@@ -310,6 +397,65 @@ func CreateValidDiskName(volumeName string) string {
 	return diskName
 }
 
+// DiskNameTemplateValues are the values a diskNameTemplate can refer to.
+type DiskNameTemplateValues struct {
+	PVCNamespace string
+	PVCName      string
+	PVName       string
+	// Random is derived from PVName so that retried CreateVolume calls render the same disk name
+	Random string
+}
+
+// NewDiskNameTemplateValues returns the diskNameTemplate values for the given PVC and PV
+func NewDiskNameTemplateValues(pvcNamespace, pvcName, pvName string) DiskNameTemplateValues {
+	hash := sha256.Sum256([]byte(pvName))
+	return DiskNameTemplateValues{
+		PVCNamespace: pvcNamespace,
+		PVCName:      pvcName,
+		PVName:       pvName,
+		Random:       hex.EncodeToString(hash[:])[:diskNameRandomLength],
+	}
+}
+
+// ValidateDiskNameTemplate checks that diskNameTemplate parses and renders a different disk name for every PV,
+// i.e. refers to {{.PVName}} or {{.Random}}. A disk name cannot be reserved before the disk is created, so a
+// template rendering the same name for two PVs, e.g. after a PVC is recreated, would make them share one disk.
+func ValidateDiskNameTemplate(diskNameTemplate string) error {
+	tmpl, err := template.New(consts.DiskNameTemplateField).Option("missingkey=error").Parse(diskNameTemplate)
+	if err != nil {
+		return fmt.Errorf("parse %s failed with error: %v", diskNameTemplate, err)
+	}
+	var names []string
+	for _, pvName := range []string{"pvc-1", "pvc-2"} {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, NewDiskNameTemplateValues("namespace", "pvc", pvName)); err != nil {
+			return fmt.Errorf("render %s failed with error: %v", diskNameTemplate, err)
+		}
+		names = append(names, buf.String())
+	}
+	if names[0] == names[1] {
+		return fmt.Errorf("%s must refer to {{.PVName}} or {{.Random}} to render a unique disk name for every PV", diskNameTemplate)
+	}
+	return nil
+}
+
+// GenerateDiskNameFromTemplate renders diskNameTemplate with values and validates the result against the Azure disk naming rules
+func GenerateDiskNameFromTemplate(diskNameTemplate string, values DiskNameTemplateValues) (string, error) {
+	tmpl, err := template.New(consts.DiskNameTemplateField).Option("missingkey=error").Parse(diskNameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse %s failed with error: %v", diskNameTemplate, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return "", fmt.Errorf("render %s failed with error: %v", diskNameTemplate, err)
+	}
+	diskName := buf.String()
+	if len(diskName) < diskNameMinLength || len(diskName) > diskNameMaxLength || !checkDiskName(diskName) {
+		return "", fmt.Errorf("disk name (%q) rendered from %s is invalid, it must be 1-80 characters of letters, numbers, underscores, periods or hyphens, start with a letter or number and end with a letter, number or underscore", diskName, diskNameTemplate)
+	}
+	return diskName, nil
+}
+
 func GetFStype(attributes map[string]string) string {
 	for k, v := range attributes {
 		switch strings.ToLower(k) {
@@ -320,6 +466,26 @@ func GetFStype(attributes map[string]string) string {
 	return ""
 }
 
+// GetFsParams returns the options passed to mkfs when formatting the disk, specified by "fsParams" in attributes
+func GetFsParams(attributes map[string]string) []string {
+	for k, v := range attributes {
+		if strings.EqualFold(k, consts.FsParamsField) {
+			return strings.Fields(v)
+		}
+	}
+	return nil
+}
+
+// IsLuksEncrypted returns whether the volume is encrypted with LUKS on the node, specified by "encrypted" in attributes
+func IsLuksEncrypted(attributes map[string]string) bool {
+	for k, v := range attributes {
+		if strings.EqualFold(k, consts.EncryptedField) {
+			return strings.EqualFold(v, consts.LuksEncryption)
+		}
+	}
+	return false
+}
+
 func GetMaxShares(attributes map[string]string) (int, error) {
 	for k, v := range attributes {
 		switch strings.ToLower(k) {
@@ -408,6 +574,23 @@ func IsAzureStackCloud(cloud string, disableAzureStackCloud bool) bool {
 	return !disableAzureStackCloud && strings.EqualFold(cloud, azureStackCloud)
 }
 
+// IsDiskOnlineResizeSupported returns whether disk can be expanded to requestGiB while attached to a VM.
+func IsDiskOnlineResizeSupported(disk *compute.Disk, requestGiB int32) bool {
+	if disk == nil || disk.DiskProperties == nil {
+		return false
+	}
+	if disk.Sku != nil && disk.Sku.Name == compute.DiskStorageAccountTypesUltraSSDLRS {
+		return false
+	}
+	if disk.DiskProperties.MaxShares != nil && *disk.DiskProperties.MaxShares > 1 {
+		return false
+	}
+	if disk.DiskProperties.DiskSizeGB != nil && *disk.DiskProperties.DiskSizeGB <= onlineResizeMaxDiskSizeGiB && requestGiB > onlineResizeMaxDiskSizeGiB {
+		return false
+	}
+	return true
+}
+
 // IsValidAvailabilityZone returns true if the zone is in format of <region>-<zone-id>.
 func IsValidAvailabilityZone(zone, region string) bool {
 	return strings.HasPrefix(zone, fmt.Sprintf("%s-", region))
@@ -491,6 +674,34 @@ func NormalizeNetworkAccessPolicy(networkAccessPolicy string) (compute.NetworkAc
 	return "", fmt.Errorf("azureDisk - %s is not supported NetworkAccessPolicy. Supported values are %s", networkAccessPolicy, compute.PossibleNetworkAccessPolicyValues())
 }
 
+// NormalizePublicNetworkAccess returns an empty value if publicNetworkAccess is not set so that the platform default is kept.
+func NormalizePublicNetworkAccess(publicNetworkAccess string) (compute.PublicNetworkAccess, error) {
+	if publicNetworkAccess == "" {
+		return "", nil
+	}
+	access := compute.PublicNetworkAccess(publicNetworkAccess)
+	for _, s := range compute.PossiblePublicNetworkAccessValues() {
+		if access == s {
+			return access, nil
+		}
+	}
+	return "", fmt.Errorf("azureDisk - %s is not supported PublicNetworkAccess. Supported values are %s", publicNetworkAccess, compute.PossiblePublicNetworkAccessValues())
+}
+
+// ValidateDiskAccessID checks that diskAccessID is a DiskAccess resource ID and is only used with the AllowPrivate network access policy.
+func ValidateDiskAccessID(diskAccessID string, networkAccessPolicy compute.NetworkAccessPolicy) error {
+	if diskAccessID == "" {
+		return nil
+	}
+	if networkAccessPolicy != compute.NetworkAccessPolicyAllowPrivate {
+		return fmt.Errorf("azureDisk - diskAccessID is only supported with networkAccessPolicy %s, requested: %s", compute.NetworkAccessPolicyAllowPrivate, networkAccessPolicy)
+	}
+	if !diskAccessIDRE.MatchString(diskAccessID) {
+		return fmt.Errorf("azureDisk - diskAccessID(%s) is invalid, correct format: %s", diskAccessID, diskAccessIDRE)
+	}
+	return nil
+}
+
 func NormalizeStorageAccountType(storageAccountType, cloud string, disableAzureStackCloud bool) (compute.DiskStorageAccountTypes, error) {
 	if storageAccountType == "" {
 		if IsAzureStackCloud(cloud, disableAzureStackCloud) {
@@ -526,6 +737,93 @@ func ValidateDiskEncryptionType(encryptionType string) error {
 	return fmt.Errorf("DiskEncryptionType(%s) is not supported", encryptionType)
 }
 
+// ValidateMountOptions checks that mountFlags do not contain options specific to a filesystem other than fsType.
+// Options that are not filesystem specific are not validated and are left to mount.
+func ValidateMountOptions(fsType string, mountFlags []string) error {
+	fsFamily := strings.ToLower(fsType)
+	if strings.HasPrefix(fsFamily, "ext") {
+		fsFamily = "ext"
+	}
+	for _, flag := range mountFlags {
+		option := strings.SplitN(flag, "=", 2)[0]
+		for family, options := range fsSpecificMountOptions {
+			if family != fsFamily && options.Has(option) {
+				return fmt.Errorf("azureDisk - mount option %s is not supported by fsType %s, it is only supported by %s", flag, fsType, family)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDiskBursting checks that on-demand bursting can be enabled on a disk of the given sku and size.
+func ValidateDiskBursting(enableBursting *bool, skuName compute.DiskStorageAccountTypes, diskSizeGiB int) error {
+	if enableBursting == nil || !*enableBursting {
+		return nil
+	}
+	if skuName != compute.DiskStorageAccountTypesPremiumLRS && skuName != compute.DiskStorageAccountTypesPremiumZRS {
+		return fmt.Errorf("azureDisk - on-demand bursting is not supported for sku/storageaccounttype %s. Supported values are %s", skuName, []compute.DiskStorageAccountTypes{compute.DiskStorageAccountTypesPremiumLRS, compute.DiskStorageAccountTypesPremiumZRS})
+	}
+	if diskSizeGiB <= burstingMinDiskSizeGiB {
+		return fmt.Errorf("azureDisk - on-demand bursting is only supported on disks larger than %d GiB, requested size: %d GiB", burstingMinDiskSizeGiB, diskSizeGiB)
+	}
+	return nil
+}
+
+// ValidatePerformanceTier checks that tier is a valid performance tier for a disk of the given sku.
+// Performance tiers can only be set on premium SSDs, see https://docs.microsoft.com/en-us/azure/virtual-machines/disks-change-performance
+func ValidatePerformanceTier(tier string, skuName compute.DiskStorageAccountTypes) error {
+	if tier == "" {
+		return nil
+	}
+	if skuName != compute.DiskStorageAccountTypesPremiumLRS && skuName != compute.DiskStorageAccountTypesPremiumZRS {
+		return fmt.Errorf("azureDisk - performance tier(%s) is not supported for sku/storageaccounttype %s. Supported values are %s", tier, skuName, []compute.DiskStorageAccountTypes{compute.DiskStorageAccountTypesPremiumLRS, compute.DiskStorageAccountTypesPremiumZRS})
+	}
+	if !performanceTierRE.MatchString(tier) {
+		return fmt.Errorf("azureDisk - performance tier(%s) is invalid, correct format: %s", tier, performanceTierRE)
+	}
+	return nil
+}
+
+// ValidateLogicalSectorSize checks that logicalSectorSize is a valid logical sector size for a disk of the given sku.
+func ValidateLogicalSectorSize(logicalSectorSize int, skuName compute.DiskStorageAccountTypes) error {
+	if logicalSectorSize == 0 {
+		return nil
+	}
+	if skuName != compute.DiskStorageAccountTypesUltraSSDLRS {
+		return fmt.Errorf("azureDisk - logicalSectorSize is only supported for sku/storageaccounttype %s, requested: %s", compute.DiskStorageAccountTypesUltraSSDLRS, skuName)
+	}
+	if !supportedLogicalSectorSizes.Has(logicalSectorSize) {
+		return fmt.Errorf("azureDisk - logicalSectorSize(%d) is not supported. Supported values are %v", logicalSectorSize, supportedLogicalSectorSizes.List())
+	}
+	return nil
+}
+
+// ValidateWriteAccelerator checks that write accelerator can be enabled on a disk of the given sku and caching mode.
+// Write accelerator is only supported on premium SSDs with None or ReadOnly host caching,
+// see https://docs.microsoft.com/en-us/azure/virtual-machines/how-to-enable-write-accelerator
+func ValidateWriteAccelerator(writeAcceleratorEnabled string, skuName compute.DiskStorageAccountTypes, cachingMode v1.AzureDataDiskCachingMode) error {
+	if !strings.EqualFold(writeAcceleratorEnabled, consts.TrueValue) {
+		return nil
+	}
+	if skuName != compute.DiskStorageAccountTypesPremiumLRS && skuName != compute.DiskStorageAccountTypesPremiumZRS {
+		return fmt.Errorf("azureDisk - write accelerator is not supported for sku/storageaccounttype %s. Supported values are %s", skuName, []compute.DiskStorageAccountTypes{compute.DiskStorageAccountTypesPremiumLRS, compute.DiskStorageAccountTypesPremiumZRS})
+	}
+	cachingMode, err := NormalizeCachingMode(cachingMode)
+	if err != nil {
+		return err
+	}
+	if cachingMode == v1.AzureDataDiskCachingReadWrite {
+		return fmt.Errorf("azureDisk - write accelerator is not supported with cachingMode %s", cachingMode)
+	}
+	return nil
+}
+
+// IsWriteAcceleratorSupportedVMSize returns whether write accelerator can be enabled on disks attached to a VM of vmSize.
+// Write accelerator is only available on M-series VMs.
+func IsWriteAcceleratorSupportedVMSize(vmSize string) bool {
+	return strings.HasPrefix(strings.ToLower(vmSize), "standard_m")
+}
+
 func ParseDiskParameters(parameters map[string]string) (ManagedDiskParameters, error) {
 	var err error
 	if parameters == nil {
@@ -562,6 +860,11 @@ func ParseDiskParameters(parameters map[string]string) (ManagedDiskParameters, e
 			}
 		case consts.DiskNameField:
 			diskParams.DiskName = v
+		case consts.DiskNameTemplateField:
+			if err = ValidateDiskNameTemplate(v); err != nil {
+				return diskParams, err
+			}
+			diskParams.DiskNameTemplate = v
 		case consts.DesIDField:
 			diskParams.DiskEncryptionSetID = v
 		case consts.DiskEncryptionTypeField:
@@ -574,6 +877,10 @@ func ParseDiskParameters(parameters map[string]string) (ManagedDiskParameters, e
 			for k, v := range customTagsMap {
 				diskParams.Tags[k] = v
 			}
+		case consts.TagsFromLabelsField:
+			diskParams.TagsFromLabels = v
+		case consts.TierField:
+			diskParams.Tier = v
 		case azure.WriteAcceleratorEnabled:
 			diskParams.WriteAcceleratorEnabled = v
 		case consts.MaxSharesField:
@@ -592,6 +899,13 @@ func ParseDiskParameters(parameters map[string]string) (ManagedDiskParameters, e
 			diskParams.Tags[consts.PvNameTag] = v
 		case consts.FsTypeField:
 			diskParams.FsType = strings.ToLower(v)
+		case consts.FsParamsField:
+			diskParams.FsParams = v
+		case consts.EncryptedField:
+			if !strings.EqualFold(v, consts.LuksEncryption) {
+				return diskParams, fmt.Errorf("invalid %s: %s in storage class, supported value is %s", consts.EncryptedField, v, consts.LuksEncryption)
+			}
+			diskParams.Encrypted = consts.LuksEncryption
 		case consts.KindField:
 			// fix csi migration issue: https://github.com/kubernetes/kubernetes/issues/103433
 			diskParams.VolumeContext[consts.KindField] = string(v1.AzureManagedDisk)
@@ -604,6 +918,8 @@ func ParseDiskParameters(parameters map[string]string) (ManagedDiskParameters, e
 			diskParams.NetworkAccessPolicy = v
 		case consts.DiskAccessIDField:
 			diskParams.DiskAccessID = v
+		case consts.PublicNetworkAccessField:
+			diskParams.PublicNetworkAccess = v
 		case consts.EnableBurstingField:
 			if strings.EqualFold(v, consts.TrueValue) {
 				diskParams.EnableBursting = to.BoolPtr(true)
@@ -703,8 +1019,17 @@ func InsertDiskProperties(disk *compute.Disk, publishConext map[string]string) {
 	}
 }
 
+// GetThrottlingBackoff returns how long to back off after a throttled request, honoring the Retry-After
+// returned by ARM and falling back to defaultBackoff if there is none.
+func GetThrottlingBackoff(rerr *retry.Error, defaultBackoff time.Duration) time.Duration {
+	if rerr != nil && rerr.RetryAfter.After(time.Now()) {
+		return time.Until(rerr.RetryAfter)
+	}
+	return defaultBackoff
+}
+
 func SleepIfThrottled(err error, sleepSec int) {
-	if strings.Contains(strings.ToLower(err.Error()), strings.ToLower(azureconstants.TooManyRequests)) || strings.Contains(strings.ToLower(err.Error()), azureconstants.ClientThrottled) {
+	if IsThrottlingError(err) {
 		klog.Warningf("sleep %d more seconds, waiting for throttling complete", sleepSec)
 		time.Sleep(time.Duration(sleepSec) * time.Second)
 	}
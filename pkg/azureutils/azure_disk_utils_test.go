@@ -33,8 +33,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 	"sigs.k8s.io/azuredisk-csi-driver/test/utils/testutil"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
 func TestCheckDiskName(t *testing.T) {
@@ -395,6 +397,165 @@ users:
 	}
 }
 
+func TestGetTagsFromLabels(t *testing.T) {
+	namespaceLabels := map[string]string{"team": "storage", "cost-center": "1234"}
+	pvcLabels := map[string]string{"team": "compute", "app.kubernetes.io/name": "db"}
+	tests := []struct {
+		tagsFromLabels string
+		expectedTags   map[string]string
+	}{
+		{
+			tagsFromLabels: "",
+			expectedTags:   map[string]string{},
+		},
+		{
+			tagsFromLabels: "cost-center",
+			expectedTags:   map[string]string{"cost-center": "1234"},
+		},
+		{
+			tagsFromLabels: "team, cost-center",
+			expectedTags:   map[string]string{"team": "compute", "cost-center": "1234"},
+		},
+		{
+			tagsFromLabels: "app.kubernetes.io/name,missing",
+			expectedTags:   map[string]string{"app.kubernetes.io_name": "db"},
+		},
+	}
+
+	for _, test := range tests {
+		result := GetTagsFromLabels(test.tagsFromLabels, namespaceLabels, pvcLabels)
+		assert.Equal(t, test.expectedTags, result, test.tagsFromLabels)
+	}
+}
+
+func TestGenerateDiskNameFromTemplate(t *testing.T) {
+	values := NewDiskNameTemplateValues("default", "data-db-0", "pvc-9f8a4c1e")
+	tests := []struct {
+		diskNameTemplate string
+		expectedDiskName string
+		expectedErr      bool
+	}{
+		{
+			diskNameTemplate: "{{.PVCNamespace}}-{{.PVCName}}",
+			expectedDiskName: "default-data-db-0",
+		},
+		{
+			diskNameTemplate: "{{.PVCName}}-{{.Random}}",
+			expectedDiskName: "data-db-0-" + values.Random,
+		},
+		{
+			diskNameTemplate: "{{.PVName}}",
+			expectedDiskName: "pvc-9f8a4c1e",
+		},
+		{
+			diskNameTemplate: "{{.PVCName",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: "{{.Unknown}}",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: "{{.PVCName}}/",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: strings.Repeat("a", diskNameMaxLength+1),
+			expectedErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		diskName, err := GenerateDiskNameFromTemplate(test.diskNameTemplate, values)
+		assert.Equal(t, test.expectedErr, err != nil, test.diskNameTemplate)
+		assert.Equal(t, test.expectedDiskName, diskName, test.diskNameTemplate)
+	}
+
+	assert.Len(t, values.Random, diskNameRandomLength)
+	assert.Equal(t, values.Random, NewDiskNameTemplateValues("other", "other", "pvc-9f8a4c1e").Random)
+}
+
+func TestValidateDiskNameTemplate(t *testing.T) {
+	tests := []struct {
+		diskNameTemplate string
+		expectedErr      bool
+	}{
+		{
+			diskNameTemplate: "{{.PVCNamespace}}-{{.PVCName}}-{{.Random}}",
+		},
+		{
+			diskNameTemplate: "{{ .PVName }}",
+		},
+		{
+			diskNameTemplate: "{{.PVCNamespace}}-{{.PVCName}}",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: "disk",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: "{{.PVCName",
+			expectedErr:      true,
+		},
+		{
+			diskNameTemplate: "{{.Unknown}}-{{.Random}}",
+			expectedErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateDiskNameTemplate(test.diskNameTemplate)
+		assert.Equal(t, test.expectedErr, err != nil, test.diskNameTemplate)
+	}
+}
+
+func TestGetAllowedPVCAnnotationParameters(t *testing.T) {
+	assert.Equal(t, sets.NewString(), GetAllowedPVCAnnotationParameters(""))
+	assert.Equal(t, sets.NewString("cachingmode", "tags"), GetAllowedPVCAnnotationParameters("cachingMode, tags,"))
+}
+
+func TestOverrideParametersFromPVCAnnotations(t *testing.T) {
+	parameters := map[string]string{"skuName": "Premium_LRS", "cachingMode": "ReadOnly"}
+	allowed := sets.NewString("cachingmode", "tags")
+	tests := []struct {
+		desc           string
+		annotations    map[string]string
+		expectedParams map[string]string
+		expectedErr    bool
+	}{
+		{
+			desc:           "no annotations",
+			annotations:    nil,
+			expectedParams: map[string]string{"skuName": "Premium_LRS", "cachingMode": "ReadOnly"},
+		},
+		{
+			desc:           "unrelated annotations are ignored",
+			annotations:    map[string]string{"volume.kubernetes.io/storage-provisioner": "disk.csi.azure.com"},
+			expectedParams: map[string]string{"skuName": "Premium_LRS", "cachingMode": "ReadOnly"},
+		},
+		{
+			desc:           "allowed annotations override parameters",
+			annotations:    map[string]string{"disk.csi.azure.com/cachingMode": "None", "disk.csi.azure.com/tags": "team=storage"},
+			expectedParams: map[string]string{"skuName": "Premium_LRS", "cachingmode": "None", "tags": "team=storage"},
+		},
+		{
+			desc:        "annotation not in the allow-list",
+			annotations: map[string]string{"disk.csi.azure.com/skuName": "Standard_LRS"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := OverrideParametersFromPVCAnnotations(parameters, test.annotations, allowed)
+		assert.Equal(t, test.expectedErr, err != nil, test.desc)
+		if !test.expectedErr {
+			assert.Equal(t, test.expectedParams, result, test.desc)
+		}
+	}
+	assert.Equal(t, "ReadOnly", parameters["cachingMode"], "parameters must not be modified")
+}
+
 func TestGetDiskLUN(t *testing.T) {
 	tests := []struct {
 		deviceInfo  string
@@ -542,6 +703,139 @@ func TestGetFStype(t *testing.T) {
 	}
 }
 
+func TestGetFsParams(t *testing.T) {
+	tests := []struct {
+		options  map[string]string
+		expected []string
+	}{
+		{
+			nil,
+			nil,
+		},
+		{
+			map[string]string{"fsParams": ""},
+			[]string{},
+		},
+		{
+			map[string]string{"fsparams": "-m reflink=1,crc=1  -d su=64k,sw=4"},
+			[]string{"-m", "reflink=1,crc=1", "-d", "su=64k,sw=4"},
+		},
+	}
+
+	for _, test := range tests {
+		result := GetFsParams(test.options)
+		assert.Equal(t, test.expected, result, test.options)
+	}
+}
+
+func TestIsLuksEncrypted(t *testing.T) {
+	tests := []struct {
+		options  map[string]string
+		expected bool
+	}{
+		{
+			nil,
+			false,
+		},
+		{
+			map[string]string{"encrypted": "luks"},
+			true,
+		},
+		{
+			map[string]string{"Encrypted": "LUKS"},
+			true,
+		},
+		{
+			map[string]string{"encrypted": "none"},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		result := IsLuksEncrypted(test.options)
+		assert.Equal(t, test.expected, result, test.options)
+	}
+}
+
+func TestValidateMountOptions(t *testing.T) {
+	tests := []struct {
+		fsType      string
+		mountFlags  []string
+		expectError bool
+	}{
+		{
+			fsType:      "ext4",
+			mountFlags:  nil,
+			expectError: false,
+		},
+		{
+			fsType:      "ext4",
+			mountFlags:  []string{"noatime", "data=ordered", "barrier=0"},
+			expectError: false,
+		},
+		{
+			fsType:      "ext3",
+			mountFlags:  []string{"commit=30"},
+			expectError: false,
+		},
+		{
+			fsType:      "xfs",
+			mountFlags:  []string{"noatime", "nouuid", "allocsize=64k"},
+			expectError: false,
+		},
+		{
+			fsType:      "ext4",
+			mountFlags:  []string{"prjquota", "usrquota", "grpquota"},
+			expectError: false,
+		},
+		{
+			fsType:      "xfs",
+			mountFlags:  []string{"prjquota", "usrquota", "grpquota"},
+			expectError: false,
+		},
+		{
+			fsType:      "ext4",
+			mountFlags:  []string{"pquota"},
+			expectError: true,
+		},
+		{
+			fsType:      "xfs",
+			mountFlags:  []string{"jqfmt=vfsv0"},
+			expectError: true,
+		},
+		{
+			fsType:      "btrfs",
+			mountFlags:  []string{"compress=zstd", "ssd"},
+			expectError: false,
+		},
+		{
+			fsType:      "btrfs",
+			mountFlags:  []string{"commit=120", "barrier"},
+			expectError: false,
+		},
+		{
+			fsType:      "ext4",
+			mountFlags:  []string{"nouuid"},
+			expectError: true,
+		},
+		{
+			fsType:      "xfs",
+			mountFlags:  []string{"data=writeback"},
+			expectError: true,
+		},
+		{
+			fsType:      "XFS",
+			mountFlags:  []string{"compress=zstd"},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateMountOptions(test.fsType, test.mountFlags)
+		assert.Equal(t, test.expectError, err != nil, fmt.Sprintf("fsType: %s, mountFlags: %v, error: %v", test.fsType, test.mountFlags, err))
+	}
+}
+
 func TestGetMaxShares(t *testing.T) {
 	tests := []struct {
 		options       map[string]string
@@ -1273,6 +1567,76 @@ func TestNormalizeNetworkAccessPolicy(t *testing.T) {
 	}
 }
 
+func TestNormalizePublicNetworkAccess(t *testing.T) {
+	tests := []struct {
+		publicNetworkAccess         string
+		expectedPublicNetworkAccess compute.PublicNetworkAccess
+		expectError                 bool
+	}{
+		{
+			publicNetworkAccess:         "",
+			expectedPublicNetworkAccess: compute.PublicNetworkAccess(""),
+			expectError:                 false,
+		},
+		{
+			publicNetworkAccess:         "Enabled",
+			expectedPublicNetworkAccess: compute.PublicNetworkAccessEnabled,
+			expectError:                 false,
+		},
+		{
+			publicNetworkAccess:         "Disabled",
+			expectedPublicNetworkAccess: compute.PublicNetworkAccessDisabled,
+			expectError:                 false,
+		},
+		{
+			publicNetworkAccess:         "invalid",
+			expectedPublicNetworkAccess: compute.PublicNetworkAccess(""),
+			expectError:                 true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := NormalizePublicNetworkAccess(test.publicNetworkAccess)
+		assert.Equal(t, result, test.expectedPublicNetworkAccess)
+		assert.Equal(t, err != nil, test.expectError, fmt.Sprintf("error msg: %v", err))
+	}
+}
+
+func TestValidateDiskAccessID(t *testing.T) {
+	diskAccessID := "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/diskAccesses/access"
+	tests := []struct {
+		diskAccessID        string
+		networkAccessPolicy compute.NetworkAccessPolicy
+		expectError         bool
+	}{
+		{
+			diskAccessID:        "",
+			networkAccessPolicy: compute.NetworkAccessPolicyAllowAll,
+			expectError:         false,
+		},
+		{
+			diskAccessID:        diskAccessID,
+			networkAccessPolicy: compute.NetworkAccessPolicyAllowPrivate,
+			expectError:         false,
+		},
+		{
+			diskAccessID:        diskAccessID,
+			networkAccessPolicy: compute.NetworkAccessPolicyAllowAll,
+			expectError:         true,
+		},
+		{
+			diskAccessID:        "/subscriptions/subs/resourceGroups/rg/providers/Microsoft.Compute/disks/disk",
+			networkAccessPolicy: compute.NetworkAccessPolicyAllowPrivate,
+			expectError:         true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateDiskAccessID(test.diskAccessID, test.networkAccessPolicy)
+		assert.Equal(t, err != nil, test.expectError, fmt.Sprintf("error msg: %v", err))
+	}
+}
+
 func TestNormalizeStorageAccountType(t *testing.T) {
 	tests := []struct {
 		cloud                  string
@@ -1353,6 +1717,101 @@ func TestNormalizeStorageAccountType(t *testing.T) {
 	}
 }
 
+func TestValidateDiskBursting(t *testing.T) {
+	tests := []struct {
+		enableBursting *bool
+		skuName        compute.DiskStorageAccountTypes
+		diskSizeGiB    int
+		expectedError  bool
+	}{
+		{
+			enableBursting: nil,
+			skuName:        compute.DiskStorageAccountTypesStandardSSDLRS,
+			diskSizeGiB:    10,
+			expectedError:  false,
+		},
+		{
+			enableBursting: to.BoolPtr(false),
+			skuName:        compute.DiskStorageAccountTypesStandardSSDLRS,
+			diskSizeGiB:    10,
+			expectedError:  false,
+		},
+		{
+			enableBursting: to.BoolPtr(true),
+			skuName:        compute.DiskStorageAccountTypesPremiumLRS,
+			diskSizeGiB:    1024,
+			expectedError:  false,
+		},
+		{
+			enableBursting: to.BoolPtr(true),
+			skuName:        compute.DiskStorageAccountTypesPremiumZRS,
+			diskSizeGiB:    513,
+			expectedError:  false,
+		},
+		{
+			enableBursting: to.BoolPtr(true),
+			skuName:        compute.DiskStorageAccountTypesPremiumLRS,
+			diskSizeGiB:    512,
+			expectedError:  true,
+		},
+		{
+			enableBursting: to.BoolPtr(true),
+			skuName:        compute.DiskStorageAccountTypesStandardSSDLRS,
+			diskSizeGiB:    1024,
+			expectedError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateDiskBursting(test.enableBursting, test.skuName, test.diskSizeGiB)
+		assert.Equal(t, test.expectedError, err != nil, "skuName: %s, diskSizeGiB: %d, err: %v", test.skuName, test.diskSizeGiB, err)
+	}
+}
+
+func TestValidatePerformanceTier(t *testing.T) {
+	tests := []struct {
+		tier          string
+		skuName       compute.DiskStorageAccountTypes
+		expectedError bool
+	}{
+		{
+			tier:          "",
+			skuName:       compute.DiskStorageAccountTypesStandardSSDLRS,
+			expectedError: false,
+		},
+		{
+			tier:          "P30",
+			skuName:       compute.DiskStorageAccountTypesPremiumLRS,
+			expectedError: false,
+		},
+		{
+			tier:          "P50",
+			skuName:       compute.DiskStorageAccountTypesPremiumZRS,
+			expectedError: false,
+		},
+		{
+			tier:          "P30",
+			skuName:       compute.DiskStorageAccountTypesStandardSSDLRS,
+			expectedError: true,
+		},
+		{
+			tier:          "S30",
+			skuName:       compute.DiskStorageAccountTypesPremiumLRS,
+			expectedError: true,
+		},
+		{
+			tier:          "p30",
+			skuName:       compute.DiskStorageAccountTypesPremiumLRS,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidatePerformanceTier(test.tier, test.skuName)
+		assert.Equal(t, test.expectedError, err != nil, "tier: %s, skuName: %s, err: %v", test.tier, test.skuName, err)
+	}
+}
+
 func TestParseDiskParameters(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -1390,6 +1849,16 @@ func TestParseDiskParameters(t *testing.T) {
 			},
 			expectedError: fmt.Errorf("parse invalidValue failed with error: strconv.Atoi: parsing \"invalidValue\": invalid syntax"),
 		},
+		{
+			name:        "invalid encrypted value in parameters",
+			inputParams: map[string]string{consts.EncryptedField: "true"},
+			expectedOutput: ManagedDiskParameters{
+				Incremental:   true,
+				Tags:          make(map[string]string),
+				VolumeContext: map[string]string{consts.EncryptedField: "true"},
+			},
+			expectedError: fmt.Errorf("invalid encrypted: true in storage class, supported value is luks"),
+		},
 		{
 			name: "valid parameters input",
 			inputParams: map[string]string{
@@ -1401,8 +1870,10 @@ func TestParseDiskParameters(t *testing.T) {
 				consts.DiskMBPSReadWriteField:   "diskMBPSReadWrite",
 				consts.LogicalSectorSizeField:   "1",
 				consts.DiskNameField:            "diskName",
+				consts.DiskNameTemplateField:    "{{.PVCName}}-{{.Random}}",
 				consts.DesIDField:               "diskEncyptionSetID",
 				consts.TagsField:                "key0=value0, key1=value1",
+				consts.TierField:                "P30",
 				consts.WriteAcceleratorEnabled:  "writeAcceleratorEnabled",
 				consts.PvcNameKey:               "pvcName",
 				consts.PvcNamespaceKey:          "pvcNamespace",
@@ -1413,6 +1884,10 @@ func TestParseDiskParameters(t *testing.T) {
 				consts.PerfProfileField:         "None",
 				consts.NetworkAccessPolicyField: "networkAccessPolicy",
 				consts.DiskAccessIDField:        "diskAccessID",
+				consts.PublicNetworkAccessField: "publicNetworkAccess",
+				consts.TagsFromLabelsField:      "team",
+				consts.FsParamsField:            "-m reflink=1",
+				consts.EncryptedField:           "luks",
 				consts.EnableBurstingField:      "true",
 				consts.UserAgentField:           "userAgent",
 				consts.EnableAsyncAttachField:   "enableAsyncAttach",
@@ -1427,6 +1902,7 @@ func TestParseDiskParameters(t *testing.T) {
 				DiskIOPSReadWrite:   "diskIOPSReadWrite",
 				DiskMBPSReadWrite:   "diskMBPSReadWrite",
 				DiskName:            "diskName",
+				DiskNameTemplate:    "{{.PVCName}}-{{.Random}}",
 				DiskEncryptionSetID: "diskEncyptionSetID",
 				Incremental:         false,
 				Tags: map[string]string{
@@ -1436,12 +1912,17 @@ func TestParseDiskParameters(t *testing.T) {
 					"key0":                 "value0",
 					"key1":                 "value1",
 				},
+				Tier:                    "P30",
 				WriteAcceleratorEnabled: "writeAcceleratorEnabled",
+				FsParams:                "-m reflink=1",
 				FsType:                  "fstype",
 				PerfProfile:             "None",
 				NetworkAccessPolicy:     "networkAccessPolicy",
 				DiskAccessID:            "diskAccessID",
+				PublicNetworkAccess:     "publicNetworkAccess",
+				TagsFromLabels:          "team",
 				EnableBursting:          to.BoolPtr(true),
+				Encrypted:               "luks",
 				UserAgent:               "userAgent",
 				VolumeContext: map[string]string{
 					consts.SkuNameField:             "skuName",
@@ -1452,8 +1933,10 @@ func TestParseDiskParameters(t *testing.T) {
 					consts.DiskMBPSReadWriteField:   "diskMBPSReadWrite",
 					consts.LogicalSectorSizeField:   "1",
 					consts.DiskNameField:            "diskName",
+					consts.DiskNameTemplateField:    "{{.PVCName}}-{{.Random}}",
 					consts.DesIDField:               "diskEncyptionSetID",
 					consts.TagsField:                "key0=value0, key1=value1",
+					consts.TierField:                "P30",
 					consts.WriteAcceleratorEnabled:  "writeAcceleratorEnabled",
 					consts.PvcNameKey:               "pvcName",
 					consts.PvcNamespaceKey:          "pvcNamespace",
@@ -1464,6 +1947,10 @@ func TestParseDiskParameters(t *testing.T) {
 					consts.PerfProfileField:         "None",
 					consts.NetworkAccessPolicyField: "networkAccessPolicy",
 					consts.DiskAccessIDField:        "diskAccessID",
+					consts.PublicNetworkAccessField: "publicNetworkAccess",
+					consts.TagsFromLabelsField:      "team",
+					consts.FsParamsField:            "-m reflink=1",
+					consts.EncryptedField:           "luks",
 					consts.EnableBurstingField:      "true",
 					consts.UserAgentField:           "userAgent",
 					consts.EnableAsyncAttachField:   "enableAsyncAttach",
@@ -1684,6 +2171,48 @@ func TestInsertDiskProperties(t *testing.T) {
 	}
 }
 
+func TestGetThrottlingBackoff(t *testing.T) {
+	const defaultBackoff = 5 * time.Minute
+
+	tests := []struct {
+		description string
+		rerr        *retry.Error
+		min         time.Duration
+		max         time.Duration
+	}{
+		{
+			description: "nil error",
+			rerr:        nil,
+			min:         defaultBackoff,
+			max:         defaultBackoff,
+		},
+		{
+			description: "no Retry-After",
+			rerr:        &retry.Error{HTTPStatusCode: 429},
+			min:         defaultBackoff,
+			max:         defaultBackoff,
+		},
+		{
+			description: "Retry-After in the past",
+			rerr:        &retry.Error{HTTPStatusCode: 429, RetryAfter: time.Now().Add(-time.Minute)},
+			min:         defaultBackoff,
+			max:         defaultBackoff,
+		},
+		{
+			description: "Retry-After in the future",
+			rerr:        &retry.Error{HTTPStatusCode: 429, RetryAfter: time.Now().Add(30 * time.Second)},
+			min:         29 * time.Second,
+			max:         30 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		backoff := GetThrottlingBackoff(test.rerr, defaultBackoff)
+		assert.GreaterOrEqual(t, backoff, test.min, test.description)
+		assert.LessOrEqual(t, backoff, test.max, test.description)
+	}
+}
+
 func TestSleepIfThrottled(t *testing.T) {
 	const sleepDuration = 1 * time.Second
 
@@ -1722,3 +2251,181 @@ func TestSleepIfThrottled(t *testing.T) {
 		})
 	}
 }
+
+func TestIsDiskOnlineResizeSupported(t *testing.T) {
+	tests := []struct {
+		desc       string
+		disk       *compute.Disk
+		requestGiB int32
+		expected   bool
+	}{
+		{
+			desc:       "nil disk",
+			disk:       nil,
+			requestGiB: 20,
+			expected:   false,
+		},
+		{
+			desc: "premium disk",
+			disk: &compute.Disk{
+				Sku:            &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+				DiskProperties: &compute.DiskProperties{DiskSizeGB: to.Int32Ptr(10)},
+			},
+			requestGiB: 20,
+			expected:   true,
+		},
+		{
+			desc: "ultra disk",
+			disk: &compute.Disk{
+				Sku:            &compute.DiskSku{Name: compute.DiskStorageAccountTypesUltraSSDLRS},
+				DiskProperties: &compute.DiskProperties{DiskSizeGB: to.Int32Ptr(10)},
+			},
+			requestGiB: 20,
+			expected:   false,
+		},
+		{
+			desc: "shared disk",
+			disk: &compute.Disk{
+				Sku:            &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+				DiskProperties: &compute.DiskProperties{DiskSizeGB: to.Int32Ptr(256), MaxShares: to.Int32Ptr(2)},
+			},
+			requestGiB: 512,
+			expected:   false,
+		},
+		{
+			desc: "expand beyond 4 TiB",
+			disk: &compute.Disk{
+				Sku:            &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+				DiskProperties: &compute.DiskProperties{DiskSizeGB: to.Int32Ptr(4096)},
+			},
+			requestGiB: 8192,
+			expected:   false,
+		},
+		{
+			desc: "expand disk larger than 4 TiB",
+			disk: &compute.Disk{
+				Sku:            &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+				DiskProperties: &compute.DiskProperties{DiskSizeGB: to.Int32Ptr(8192)},
+			},
+			requestGiB: 16384,
+			expected:   true,
+		},
+	}
+
+	for _, test := range tests {
+		result := IsDiskOnlineResizeSupported(test.disk, test.requestGiB)
+		assert.Equal(t, test.expected, result, test.desc)
+	}
+}
+
+func TestValidateLogicalSectorSize(t *testing.T) {
+	tests := []struct {
+		logicalSectorSize int
+		skuName           compute.DiskStorageAccountTypes
+		expectedError     bool
+	}{
+		{
+			logicalSectorSize: 0,
+			skuName:           compute.DiskStorageAccountTypesPremiumLRS,
+			expectedError:     false,
+		},
+		{
+			logicalSectorSize: 512,
+			skuName:           compute.DiskStorageAccountTypesUltraSSDLRS,
+			expectedError:     false,
+		},
+		{
+			logicalSectorSize: 4096,
+			skuName:           compute.DiskStorageAccountTypesUltraSSDLRS,
+			expectedError:     false,
+		},
+		{
+			logicalSectorSize: 1024,
+			skuName:           compute.DiskStorageAccountTypesUltraSSDLRS,
+			expectedError:     true,
+		},
+		{
+			logicalSectorSize: 512,
+			skuName:           compute.DiskStorageAccountTypesPremiumLRS,
+			expectedError:     true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateLogicalSectorSize(test.logicalSectorSize, test.skuName)
+		assert.Equal(t, test.expectedError, err != nil, "logicalSectorSize: %d, skuName: %s, err: %v", test.logicalSectorSize, test.skuName, err)
+	}
+}
+
+func TestValidateWriteAccelerator(t *testing.T) {
+	tests := []struct {
+		writeAcceleratorEnabled string
+		skuName                 compute.DiskStorageAccountTypes
+		cachingMode             v1.AzureDataDiskCachingMode
+		expectedError           bool
+	}{
+		{
+			writeAcceleratorEnabled: "",
+			skuName:                 compute.DiskStorageAccountTypesStandardSSDLRS,
+			cachingMode:             v1.AzureDataDiskCachingReadWrite,
+			expectedError:           false,
+		},
+		{
+			writeAcceleratorEnabled: "true",
+			skuName:                 compute.DiskStorageAccountTypesPremiumLRS,
+			cachingMode:             "",
+			expectedError:           false,
+		},
+		{
+			writeAcceleratorEnabled: "true",
+			skuName:                 compute.DiskStorageAccountTypesPremiumLRS,
+			cachingMode:             v1.AzureDataDiskCachingNone,
+			expectedError:           false,
+		},
+		{
+			writeAcceleratorEnabled: "true",
+			skuName:                 compute.DiskStorageAccountTypesPremiumLRS,
+			cachingMode:             v1.AzureDataDiskCachingReadWrite,
+			expectedError:           true,
+		},
+		{
+			writeAcceleratorEnabled: "true",
+			skuName:                 compute.DiskStorageAccountTypesStandardSSDLRS,
+			cachingMode:             v1.AzureDataDiskCachingNone,
+			expectedError:           true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateWriteAccelerator(test.writeAcceleratorEnabled, test.skuName, test.cachingMode)
+		assert.Equal(t, test.expectedError, err != nil, "skuName: %s, cachingMode: %s, err: %v", test.skuName, test.cachingMode, err)
+	}
+}
+
+func TestIsWriteAcceleratorSupportedVMSize(t *testing.T) {
+	tests := []struct {
+		vmSize   string
+		expected bool
+	}{
+		{
+			vmSize:   "Standard_M128s",
+			expected: true,
+		},
+		{
+			vmSize:   "standard_m416ms_v2",
+			expected: true,
+		},
+		{
+			vmSize:   "Standard_D4s_v3",
+			expected: false,
+		},
+		{
+			vmSize:   "",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsWriteAcceleratorSupportedVMSize(test.vmSize), test.vmSize)
+	}
+}
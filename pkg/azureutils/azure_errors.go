@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorClass tells whether retrying a failed Azure API call can succeed
+type errorClass string
+
+const (
+	// errorClassRetriable errors are transient and the operation may succeed if retried
+	errorClassRetriable errorClass = "Retriable"
+	// errorClassThrottled errors are returned when the request was throttled by Azure or by the client rate limiter,
+	// the operation may succeed if retried after backing off
+	errorClassThrottled errorClass = "Throttled"
+	// errorClassNonRetriable errors will not go away by retrying without a change on the Azure side
+	errorClassNonRetriable errorClass = "NonRetriable"
+	// errorClassUserError errors are caused by the request, e.g. invalid StorageClass parameters or missing permissions
+	errorClassUserError errorClass = "UserError"
+)
+
+// ErrorClassification describes an Azure API error code
+type ErrorClassification struct {
+	// Code is the ARM error code, empty if it could not be determined
+	Code string
+	// Class tells whether the error is retriable
+	Class errorClass
+	// GRPCCode is the CSI status code returned for the error
+	GRPCCode codes.Code
+	// Action is a suggested action for the user, empty if there is none
+	Action string
+}
+
+const quotaAction = "check the subscription quota and the limits of the disk SKU"
+
+var (
+	// matches the ARM error code in both `Code="..."` and `"code": "..."` formats
+	armErrorCodeRE = regexp.MustCompile(`(?i)"?code"?\s*[:=]\s*"([a-z0-9_.]+)"`)
+
+	// armErrorClassifications is the table of known ARM error codes.
+	// Errors whose code cannot be parsed are matched by searching the error message for these codes, in order.
+	armErrorClassifications = []ErrorClassification{
+		{Code: "TooManyRequests", Class: errorClassThrottled, GRPCCode: codes.Unavailable, Action: "the request was throttled by Azure, it will be retried"},
+		{Code: "SubscriptionRequestsThrottled", Class: errorClassThrottled, GRPCCode: codes.Unavailable, Action: "the subscription is throttled by Azure, it will be retried"},
+		{Code: "client throttled", Class: errorClassThrottled, GRPCCode: codes.Unavailable, Action: "the request was throttled by the driver rate limiter, it will be retried"},
+		{Code: "rate limited", Class: errorClassThrottled, GRPCCode: codes.Unavailable, Action: "the request was throttled by the driver rate limiter, it will be retried"},
+		{Code: "OperationPreempted", Class: errorClassRetriable, GRPCCode: codes.Aborted},
+		{Code: "RetryableError", Class: errorClassRetriable, GRPCCode: codes.Unavailable},
+		{Code: "InternalServerError", Class: errorClassRetriable, GRPCCode: codes.Internal},
+		{Code: "ZonalAllocationFailed", Class: errorClassRetriable, GRPCCode: codes.ResourceExhausted, Action: "the zone is out of capacity, retry later or use another zone"},
+		{Code: "AuthorizationFailed", Class: errorClassUserError, GRPCCode: codes.PermissionDenied, Action: "grant the driver identity access to the resource"},
+		{Code: "LinkedAuthorizationFailed", Class: errorClassUserError, GRPCCode: codes.PermissionDenied, Action: "grant the driver identity access to the linked resource, e.g. the disk encryption set or snapshot"},
+		{Code: "QuotaExceeded", Class: errorClassUserError, GRPCCode: codes.ResourceExhausted, Action: quotaAction},
+		{Code: "OperationNotAllowed", Class: errorClassUserError, GRPCCode: codes.FailedPrecondition},
+		{Code: "SkuNotAvailable", Class: errorClassUserError, GRPCCode: codes.InvalidArgument, Action: "use a disk SKU that is available in the location and zone"},
+		{Code: "InvalidParameter", Class: errorClassUserError, GRPCCode: codes.InvalidArgument, Action: "fix the StorageClass or VolumeSnapshotClass parameters"},
+		{Code: "BadRequest", Class: errorClassUserError, GRPCCode: codes.InvalidArgument, Action: "fix the StorageClass or VolumeSnapshotClass parameters"},
+		{Code: "ResourceGroupNotFound", Class: errorClassNonRetriable, GRPCCode: codes.NotFound, Action: "create the resource group or fix the resourceGroup parameter"},
+		{Code: "ResourceNotFound", Class: errorClassNonRetriable, GRPCCode: codes.NotFound},
+		{Code: "NotFound", Class: errorClassNonRetriable, GRPCCode: codes.NotFound},
+	}
+)
+
+// ClassifyError returns the classification of an error returned by an Azure API call.
+// Unknown errors are classified as retriable with codes.Internal.
+func ClassifyError(err error) ErrorClassification {
+	if err == nil {
+		return ErrorClassification{Class: errorClassRetriable, GRPCCode: codes.OK}
+	}
+
+	msg := err.Error()
+	code := ""
+	if matches := armErrorCodeRE.FindStringSubmatch(msg); len(matches) == 2 {
+		code = matches[1]
+		for _, c := range armErrorClassifications {
+			if strings.EqualFold(code, c.Code) {
+				return refineClassification(c, msg)
+			}
+		}
+	}
+	for _, c := range armErrorClassifications {
+		if strings.Contains(msg, c.Code) {
+			return refineClassification(c, msg)
+		}
+	}
+	return ErrorClassification{Code: code, Class: errorClassRetriable, GRPCCode: codes.Internal}
+}
+
+// refineClassification adjusts the classification of error codes that cover several causes using the error message.
+// OperationNotAllowed is returned both for exceeded quotas and for operations not allowed in the current disk state.
+func refineClassification(c ErrorClassification, msg string) ErrorClassification {
+	if c.Code == "OperationNotAllowed" && strings.Contains(strings.ToLower(msg), "quota") {
+		c.GRPCCode = codes.ResourceExhausted
+		c.Action = quotaAction
+	}
+	return c
+}
+
+// IsThrottlingError returns true if the Azure API call that returned err was throttled by Azure or by the client rate limiter
+func IsThrottlingError(err error) bool {
+	return err != nil && ClassifyError(err).Class == errorClassThrottled
+}
+
+// NewStatusFromAzureError returns a gRPC status error with the code of the classification of err.
+// The message is prefixed with msg and suffixed with the suggested action, if any.
+func NewStatusFromAzureError(err error, msg string) error {
+	c := ClassifyError(err)
+	message := fmt.Sprintf("%s: %v", msg, err)
+	if msg == "" {
+		message = err.Error()
+	}
+	if c.Action != "" {
+		message = fmt.Sprintf("%s, suggested action: %s", message, c.Action)
+	}
+	return status.Error(c.GRPCCode, message)
+}
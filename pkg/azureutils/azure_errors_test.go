@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		desc             string
+		err              error
+		expectedCode     string
+		expectedClass    errorClass
+		expectedGRPCCode codes.Code
+	}{
+		{
+			desc:             "nil error",
+			err:              nil,
+			expectedClass:    errorClassRetriable,
+			expectedGRPCCode: codes.OK,
+		},
+		{
+			desc:             "ARM error in JSON format",
+			err:              fmt.Errorf(`Retriable: false, RetryAfter: 0s, HTTPStatusCode: 400, RawError: {"error": {"code": "InvalidParameter", "message": "The value of parameter diskSizeGB is invalid."}}`),
+			expectedCode:     "InvalidParameter",
+			expectedClass:    errorClassUserError,
+			expectedGRPCCode: codes.InvalidArgument,
+		},
+		{
+			desc:             "ARM error in autorest format",
+			err:              fmt.Errorf(`Code="AuthorizationFailed" Message="The client does not have authorization to perform action"`),
+			expectedCode:     "AuthorizationFailed",
+			expectedClass:    errorClassUserError,
+			expectedGRPCCode: codes.PermissionDenied,
+		},
+		{
+			desc:             "code is matched case insensitively",
+			err:              fmt.Errorf(`{"code": "toomanyrequests"}`),
+			expectedCode:     "TooManyRequests",
+			expectedClass:    errorClassThrottled,
+			expectedGRPCCode: codes.Unavailable,
+		},
+		{
+			desc:             "client throttled",
+			err:              fmt.Errorf("Retriable: true, RetryAfter: 5s, HTTPStatusCode: 0, RawError: azure cloud provider throttled for operation DiskGet with reason \"client throttled\""),
+			expectedCode:     "client throttled",
+			expectedClass:    errorClassThrottled,
+			expectedGRPCCode: codes.Unavailable,
+		},
+		{
+			desc:             "rate limited",
+			err:              fmt.Errorf("azure cloud provider rate limited(read) for operation \"DiskGet\""),
+			expectedCode:     "rate limited",
+			expectedClass:    errorClassThrottled,
+			expectedGRPCCode: codes.Unavailable,
+		},
+		{
+			desc:             "code found in error message",
+			err:              fmt.Errorf("Retriable: false, RetryAfter: 0s, HTTPStatusCode: 404, RawError: ResourceGroupNotFound"),
+			expectedCode:     "ResourceGroupNotFound",
+			expectedClass:    errorClassNonRetriable,
+			expectedGRPCCode: codes.NotFound,
+		},
+		{
+			desc:             "quota exceeded",
+			err:              fmt.Errorf(`{"code": "QuotaExceeded", "message": "Operation results in exceeding quota limits of Core."}`),
+			expectedCode:     "QuotaExceeded",
+			expectedClass:    errorClassUserError,
+			expectedGRPCCode: codes.ResourceExhausted,
+		},
+		{
+			desc:             "operation not allowed by quota",
+			err:              fmt.Errorf(`{"code": "OperationNotAllowed", "message": "Operation could not be completed as it results in exceeding approved Total Regional Cores quota."}`),
+			expectedCode:     "OperationNotAllowed",
+			expectedClass:    errorClassUserError,
+			expectedGRPCCode: codes.ResourceExhausted,
+		},
+		{
+			desc:             "operation not allowed in disk state",
+			err:              fmt.Errorf(`{"code": "OperationNotAllowed", "message": "Disk resize is not allowed while the disk is attached to a running VM."}`),
+			expectedCode:     "OperationNotAllowed",
+			expectedClass:    errorClassUserError,
+			expectedGRPCCode: codes.FailedPrecondition,
+		},
+		{
+			desc:             "unknown code",
+			err:              fmt.Errorf(`{"code": "SomethingElse"}`),
+			expectedCode:     "SomethingElse",
+			expectedClass:    errorClassRetriable,
+			expectedGRPCCode: codes.Internal,
+		},
+		{
+			desc:             "no code",
+			err:              fmt.Errorf("connection reset by peer"),
+			expectedClass:    errorClassRetriable,
+			expectedGRPCCode: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		result := ClassifyError(test.err)
+		assert.Equal(t, test.expectedCode, result.Code, test.desc)
+		assert.Equal(t, test.expectedClass, result.Class, test.desc)
+		assert.Equal(t, test.expectedGRPCCode, result.GRPCCode, test.desc)
+	}
+}
+
+func TestIsThrottlingError(t *testing.T) {
+	assert.True(t, IsThrottlingError(fmt.Errorf(`{"code": "TooManyRequests"}`)))
+	assert.True(t, IsThrottlingError(fmt.Errorf("client throttled")))
+	assert.False(t, IsThrottlingError(fmt.Errorf(`{"code": "InternalServerError"}`)))
+	assert.False(t, IsThrottlingError(nil))
+}
+
+func TestNewStatusFromAzureError(t *testing.T) {
+	err := NewStatusFromAzureError(fmt.Errorf(`{"code": "SkuNotAvailable"}`), "create disk failed")
+	assert.Equal(t, status.Error(codes.InvalidArgument, `create disk failed: {"code": "SkuNotAvailable"}, suggested action: use a disk SKU that is available in the location and zone`), err)
+
+	err = NewStatusFromAzureError(fmt.Errorf(`{"code": "OperationNotAllowed", "message": "exceeding quota"}`), "")
+	assert.Equal(t, status.Error(codes.ResourceExhausted, `{"code": "OperationNotAllowed", "message": "exceeding quota"}, suggested action: check the subscription quota and the limits of the disk SKU`), err)
+
+	err = NewStatusFromAzureError(fmt.Errorf(`{"code": "OperationNotAllowed", "message": "disk is attached"}`), "")
+	assert.Equal(t, status.Error(codes.FailedPrecondition, `{"code": "OperationNotAllowed", "message": "disk is attached"}`), err)
+
+	err = NewStatusFromAzureError(fmt.Errorf("disk not found: NotFound"), "")
+	assert.Equal(t, status.Error(codes.NotFound, "disk not found: NotFound"), err)
+}
@@ -39,6 +39,11 @@ func GenerateCSISnapshot(sourceVolumeID string, snapshot *compute.Snapshot) (*cs
 		return nil, fmt.Errorf("failed to convert timestamp(%v)", snapshot.SnapshotProperties.TimeCreated.ToTime())
 	}
 	ready, _ := isCSISnapshotReady(*snapshot.SnapshotProperties.ProvisioningState)
+	// a snapshot created by a background copy (e.g. a cross-region copy) reports
+	// provisioningState succeeded before the copy has completed
+	if ready && snapshot.SnapshotProperties.CompletionPercent != nil && *snapshot.SnapshotProperties.CompletionPercent < 100 {
+		ready = false
+	}
 
 	if snapshot.SnapshotProperties.DiskSizeGB == nil {
 		return nil, fmt.Errorf("diskSizeGB of snapshot property is nil")
@@ -97,6 +97,31 @@ func TestGenerateCSISnapshot(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "snapshot copy in progress",
+			testFunc: func(t *testing.T) {
+				provisioningState := "succeeded"
+				DiskSize := int32(10)
+				snapshotID := "test"
+				completionPercent := float64(42)
+				snapshot := compute.Snapshot{
+					SnapshotProperties: &compute.SnapshotProperties{
+						TimeCreated:       &date.Time{},
+						ProvisioningState: &provisioningState,
+						DiskSizeGB:        &DiskSize,
+						CompletionPercent: &completionPercent,
+					},
+					ID: &snapshotID,
+				}
+				response, err := GenerateCSISnapshot("unit-test", &snapshot)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if response.ReadyToUse {
+					t.Errorf("expected snapshot with completionPercent %v to not be ready", completionPercent)
+				}
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -94,6 +94,13 @@ func (handler *fakeIOHandler) ReadDir(dirname string) ([]os.FileInfo, error) {
 			name: devName1,
 		}
 		return []os.FileInfo{n}, nil
+	case "/sys/class/nvme/":
+		n := &fakeFileInfo{
+			name: "nvme0",
+		}
+		return []os.FileInfo{n}, nil
+	case "/sys/class/nvme/nvme0":
+		return []os.FileInfo{&fakeFileInfo{name: "model"}, &fakeFileInfo{name: "nvme0n1"}, &fakeFileInfo{name: "nvme0n3"}}, nil
 	case "/sys/class/scsi_host/":
 		n := &fakeFileInfo{
 			name: "host0",
@@ -109,10 +116,21 @@ func (handler *fakeIOHandler) WriteFile(filename string, data []byte, perm os.Fi
 }
 
 func (handler *fakeIOHandler) Readlink(name string) (string, error) {
+	if strings.HasPrefix(name, "/dev/disk/azure/data/by-lun/") {
+		return "", fmt.Errorf("not a link")
+	}
 	return "/dev/azure/disk/sda", nil
 }
 
 func (handler *fakeIOHandler) ReadFile(filename string) ([]byte, error) {
+	switch filename {
+	case "/sys/class/nvme/nvme0/model":
+		return []byte("MSFT NVMe Accelerator v1.0              \n"), nil
+	case "/sys/class/nvme/nvme0/nvme0n1/nsid":
+		return []byte("1\n"), nil
+	case "/sys/class/nvme/nvme0/nvme0n3/nsid":
+		return []byte("3\n"), nil
+	}
 	if strings.HasSuffix(filename, "vendor") {
 		return []byte("Msft    \n"), nil
 	}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/klog/v2"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	// LuksFormat is the disk format reported by blkid for a LUKS device.
+	LuksFormat = "crypto_LUKS"
+
+	mapperDir    = "/dev/mapper/"
+	mapperPrefix = "luks-"
+	// uriHashLength is the number of hex characters of the disk URI hash in a mapper name
+	uriHashLength = 8
+)
+
+// MapperName returns the device mapper name of the LUKS device opened for the disk diskURI.
+// Disk names are only unique within a resource group, so the name ends with a hash of the
+// case-insensitive disk URI to tell apart disks with the same name attached to one node.
+func MapperName(diskURI string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(diskURI)))
+	return fmt.Sprintf("%s%s-%s", mapperPrefix, path.Base(diskURI), hex.EncodeToString(hash[:])[:uriHashLength])
+}
+
+// MapperPath returns the path of the LUKS device opened for the disk diskURI.
+func MapperPath(diskURI string) string {
+	return mapperDir + MapperName(diskURI)
+}
+
+// GetMapperName returns the device mapper name if devicePath is a LUKS device opened by the driver.
+func GetMapperName(devicePath string) (string, bool) {
+	if !strings.HasPrefix(devicePath, mapperDir+mapperPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(devicePath, mapperDir), true
+}
+
+// Crypt manages LUKS2 encrypted devices with cryptsetup.
+type Crypt struct {
+	exec utilexec.Interface
+}
+
+// New returns a Crypt that runs cryptsetup with exec.
+func New(exec utilexec.Interface) *Crypt {
+	return &Crypt{exec: exec}
+}
+
+// Format formats device as a LUKS2 device protected by passphrase. Any data on device is lost.
+func (c *Crypt) Format(device, passphrase string) error {
+	klog.V(2).Infof("formatting %s as LUKS2 device", device)
+	_, err := c.run(passphrase, "luksFormat", "-q", "--type", "luks2", "--key-file", "-", device)
+	return err
+}
+
+// Open opens the LUKS device on device as /dev/mapper/name.
+func (c *Crypt) Open(device, name, passphrase string) error {
+	klog.V(2).Infof("opening LUKS device %s as %s", device, name)
+	_, err := c.run(passphrase, "luksOpen", "--key-file", "-", device, name)
+	return err
+}
+
+// Close closes the LUKS device /dev/mapper/name.
+func (c *Crypt) Close(name string) error {
+	klog.V(2).Infof("closing LUKS device %s", name)
+	_, err := c.run("", "luksClose", name)
+	return err
+}
+
+// Resize grows the LUKS device /dev/mapper/name to the size of its backing device.
+// The volume key of a LUKS2 device is kept in the kernel keyring, so no passphrase is needed.
+func (c *Crypt) Resize(name string) error {
+	klog.V(2).Infof("resizing LUKS device %s", name)
+	_, err := c.run("", "resize", name)
+	return err
+}
+
+// GetBackingDevice returns the device the LUKS device /dev/mapper/name is opened on.
+func (c *Crypt) GetBackingDevice(name string) (string, error) {
+	output, err := c.run("", "status", name)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "device:" {
+			return fields[1], nil
+		}
+	}
+	return "", fmt.Errorf("could not get backing device of %s from cryptsetup status output: %s", name, string(output))
+}
+
+func (c *Crypt) run(passphrase string, args ...string) ([]byte, error) {
+	cmd := c.exec.Command("cryptsetup", args...)
+	if passphrase != "" {
+		cmd.SetStdin(strings.NewReader(passphrase))
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return output, fmt.Errorf("cryptsetup %s failed with %v, output: %s", args[0], err, string(output))
+	}
+	return output, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+)
+
+func newFakeExec(output string, err error) (*testingexec.FakeExec, *testingexec.FakeCmd) {
+	fakeCmd := &testingexec.FakeCmd{
+		CombinedOutputScript: []testingexec.FakeAction{
+			func() ([]byte, []byte, error) { return []byte(output), nil, err },
+		},
+	}
+	fakeExec := &testingexec.FakeExec{
+		CommandScript: []testingexec.FakeCommandAction{
+			func(cmd string, args ...string) exec.Cmd {
+				return testingexec.InitFakeCmd(fakeCmd, cmd, args...)
+			},
+		},
+	}
+	return fakeExec, fakeCmd
+}
+
+func TestMapperPath(t *testing.T) {
+	diskURI := "/subscriptions/sub/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk"
+	name := MapperName(diskURI)
+	assert.Regexp(t, "^luks-disk-[0-9a-f]{8}$", name)
+	assert.Equal(t, "/dev/mapper/"+name, MapperPath(diskURI))
+	assert.Equal(t, name, MapperName("/subscriptions/sub/resourcegroups/RG1/providers/microsoft.compute/disks/disk"))
+	assert.NotEqual(t, name, MapperName("/subscriptions/sub/resourceGroups/rg2/providers/Microsoft.Compute/disks/disk"))
+
+	name, ok := GetMapperName("/dev/mapper/luks-disk")
+	assert.True(t, ok)
+	assert.Equal(t, "luks-disk", name)
+
+	_, ok = GetMapperName("/dev/sdc")
+	assert.False(t, ok)
+}
+
+func TestCryptCommands(t *testing.T) {
+	tests := []struct {
+		desc         string
+		run          func(c *Crypt) error
+		expectedArgs []string
+	}{
+		{
+			desc:         "format",
+			run:          func(c *Crypt) error { return c.Format("/dev/sdc", "secret") },
+			expectedArgs: []string{"cryptsetup", "luksFormat", "-q", "--type", "luks2", "--key-file", "-", "/dev/sdc"},
+		},
+		{
+			desc:         "open",
+			run:          func(c *Crypt) error { return c.Open("/dev/sdc", "luks-disk", "secret") },
+			expectedArgs: []string{"cryptsetup", "luksOpen", "--key-file", "-", "/dev/sdc", "luks-disk"},
+		},
+		{
+			desc:         "close",
+			run:          func(c *Crypt) error { return c.Close("luks-disk") },
+			expectedArgs: []string{"cryptsetup", "luksClose", "luks-disk"},
+		},
+		{
+			desc:         "resize",
+			run:          func(c *Crypt) error { return c.Resize("luks-disk") },
+			expectedArgs: []string{"cryptsetup", "resize", "luks-disk"},
+		},
+	}
+
+	for _, test := range tests {
+		fakeExec, fakeCmd := newFakeExec("", nil)
+		err := test.run(New(fakeExec))
+		assert.NoError(t, err, test.desc)
+		assert.Equal(t, test.expectedArgs, fakeCmd.CombinedOutputLog[0], test.desc)
+	}
+
+	fakeExec, _ := newFakeExec("No key available with this passphrase.", fmt.Errorf("exit status 2"))
+	err := New(fakeExec).Open("/dev/sdc", "luks-disk", "wrong")
+	assert.EqualError(t, err, "cryptsetup luksOpen failed with exit status 2, output: No key available with this passphrase.")
+}
+
+func TestGetBackingDevice(t *testing.T) {
+	status := `/dev/mapper/luks-disk is active and is in use.
+  type:    LUKS2
+  cipher:  aes-xts-plain64
+  keysize: 512 bits
+  key location: keyring
+  device:  /dev/sdc
+  sector size:  512
+  offset:  32768 sectors
+  size:    20938752 sectors
+  mode:    read/write
+`
+	fakeExec, _ := newFakeExec(status, nil)
+	device, err := New(fakeExec).GetBackingDevice("luks-disk")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/sdc", device)
+
+	fakeExec, _ = newFakeExec("/dev/mapper/luks-disk is inactive.", nil)
+	_, err = New(fakeExec).GetBackingDevice("luks-disk")
+	assert.Error(t, err)
+}
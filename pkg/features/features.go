@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"sync"
+
+	"k8s.io/component-base/featuregate"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// VolumeCondition reports abnormal volumes, e.g. filesystems remounted read-only, in NodeGetVolumeStats.
+	VolumeCondition featuregate.Feature = "VolumeCondition"
+
+	// LUKSEncryption allows volumes to be encrypted with LUKS on the node using the "encrypted" StorageClass parameter.
+	LUKSEncryption featuregate.Feature = "LUKSEncryption"
+)
+
+var (
+	// DefaultMutableFeatureGate is the feature gate of the driver, set by the --feature-gates flag
+	DefaultMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+	// DefaultFeatureGate is the read-only view of DefaultMutableFeatureGate
+	DefaultFeatureGate featuregate.FeatureGate = DefaultMutableFeatureGate
+
+	defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+		VolumeCondition: {Default: true, PreRelease: featuregate.Beta},
+		LUKSEncryption:  {Default: true, PreRelease: featuregate.Beta},
+	}
+
+	featureEnabled = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "azuredisk_csi_driver",
+			Name:           "feature_enabled",
+			Help:           "Whether a feature gate of the driver is enabled (1) or disabled (0), partitioned by feature name and stage.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"name", "stage"},
+	)
+	registerFeatureMetrics sync.Once
+)
+
+func init() {
+	if err := DefaultMutableFeatureGate.Add(defaultFeatureGates); err != nil {
+		panic(err)
+	}
+}
+
+// RecordFeatureGateMetrics exports the state of every known feature gate as a metric.
+// It should be called after the --feature-gates flag is parsed.
+func RecordFeatureGateMetrics(gate featuregate.FeatureGate) {
+	registerFeatureMetrics.Do(func() {
+		legacyregistry.MustRegister(featureEnabled)
+	})
+	for feature, spec := range defaultFeatureGates {
+		value := 0.0
+		if gate.Enabled(feature) {
+			value = 1.0
+		}
+		featureEnabled.WithLabelValues(string(feature), string(spec.PreRelease)).Set(value)
+	}
+}
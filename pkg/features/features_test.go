@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestDefaultFeatureGates(t *testing.T) {
+	assert.True(t, DefaultFeatureGate.Enabled(VolumeCondition))
+	assert.True(t, DefaultFeatureGate.Enabled(LUKSEncryption))
+}
+
+func TestRecordFeatureGateMetrics(t *testing.T) {
+	gate := DefaultMutableFeatureGate.DeepCopy()
+	assert.Nil(t, gate.Set("LUKSEncryption=false"))
+	RecordFeatureGateMetrics(gate)
+
+	expected := `
+# HELP azuredisk_csi_driver_feature_enabled [ALPHA] Whether a feature gate of the driver is enabled (1) or disabled (0), partitioned by feature name and stage.
+# TYPE azuredisk_csi_driver_feature_enabled gauge
+azuredisk_csi_driver_feature_enabled{name="LUKSEncryption",stage="BETA"} 0
+azuredisk_csi_driver_feature_enabled{name="VolumeCondition",stage="BETA"} 1
+`
+	assert.Nil(t, testutil.CollectAndCompare(featureEnabled, strings.NewReader(expected), "azuredisk_csi_driver_feature_enabled"))
+}
@@ -27,7 +27,7 @@ import (
 // Right now we are only supporing basic profile
 // Other advanced profiles to come later
 func IsValidPerfProfile(profile string) bool {
-	return strings.EqualFold(profile, consts.PerfProfileBasic) || strings.EqualFold(profile, consts.PerfProfileNone)
+	return strings.EqualFold(profile, consts.PerfProfileBasic) || strings.EqualFold(profile, consts.PerfProfileNVMe) || strings.EqualFold(profile, consts.PerfProfileNone)
 }
 
 // getDiskPerfAttributes gets the per tuning mode and profile set in attributes
@@ -69,7 +69,7 @@ func GetDiskPerfAttributes(attributes map[string]string) (profile, accountType,
 // isPerfTuningEnabled checks to see if perf tuning is enabled
 func isPerfTuningEnabled(profile string) bool {
 	switch strings.ToLower(profile) {
-	case consts.PerfProfileBasic:
+	case consts.PerfProfileBasic, consts.PerfProfileNVMe:
 		return true
 	default:
 		return false
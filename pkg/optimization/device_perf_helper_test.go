@@ -43,6 +43,11 @@ func TestIsValidPerfProfile(t *testing.T) {
 			profile: "basic",
 			want:    true,
 		},
+		{
+			name:    "nvme profile should return true",
+			profile: "nvme",
+			want:    true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -141,6 +146,11 @@ func TestIsPerfTuningEnabled(t *testing.T) {
 			profile: "basic",
 			want:    true,
 		},
+		{
+			name:    "nvme profile should return true",
+			profile: "nvme",
+			want:    true,
+		},
 		{
 			name:    "incorrect profile should return false",
 			profile: "blah",
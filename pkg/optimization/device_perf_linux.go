@@ -29,6 +29,8 @@ import (
 	"strings"
 
 	"k8s.io/klog/v2"
+
+	consts "sigs.k8s.io/azuredisk-csi-driver/pkg/azureconstants"
 )
 
 type DeviceHelper struct{}
@@ -80,19 +82,25 @@ func (deviceHelper *DeviceHelper) OptimizeDiskPerformance(nodeInfo *NodeInfo, de
 		return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/scheduler for device %s. Error: %v", deviceName, err)
 	}
 
-	err = echoToFile("1", filepath.Join(blockDeviceRootPath, deviceName, "queue/iosched/fifo_batch"))
-	if err != nil {
-		return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/iosched/fifo_batch for device %s. Error: %v", deviceName, err)
-	}
+	// iosched tunables only exist when an IO scheduler is in use
+	if scheduler == "mq-deadline" {
+		err = echoToFile("1", filepath.Join(blockDeviceRootPath, deviceName, "queue/iosched/fifo_batch"))
+		if err != nil {
+			return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/iosched/fifo_batch for device %s. Error: %v", deviceName, err)
+		}
 
-	err = echoToFile("1", filepath.Join(blockDeviceRootPath, deviceName, "queue/iosched/writes_starved"))
-	if err != nil {
-		return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/iosched/writes_starved for device %s. Error: %v", deviceName, err)
+		err = echoToFile("1", filepath.Join(blockDeviceRootPath, deviceName, "queue/iosched/writes_starved"))
+		if err != nil {
+			return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/iosched/writes_starved for device %s. Error: %v", deviceName, err)
+		}
 	}
 
-	err = echoToFile(queueDepth, filepath.Join(blockDeviceRootPath, deviceName, "device/queue_depth"))
-	if err != nil {
-		return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/queue_depth for device %s. Error: %v", deviceName, err)
+	// NVMe namespaces do not expose device/queue_depth
+	if !strings.EqualFold(perfProfile, consts.PerfProfileNVMe) {
+		err = echoToFile(queueDepth, filepath.Join(blockDeviceRootPath, deviceName, "device/queue_depth"))
+		if err != nil {
+			return fmt.Errorf("OptimizeDiskPerformance: Could not set queue/queue_depth for device %s. Error: %v", deviceName, err)
+		}
 	}
 
 	err = echoToFile(nrRequests, filepath.Join(blockDeviceRootPath, deviceName, "queue/nr_requests"))
@@ -195,6 +203,10 @@ func getOptimalDeviceSettings(nodeInfo *NodeInfo, diskSkus map[string]map[string
 
 	maxSectorsKb = fmt.Sprintf("%g", rsMinSeqIo)
 	scheduler = "mq-deadline"
+	if strings.EqualFold(perfProfile, consts.PerfProfileNVMe) {
+		// NVMe devices have deep hardware queues, so skip IO scheduling in the kernel
+		scheduler = "none"
+	}
 
 	qdTotal := fmt.Sprintf("%g", math.Ceil(qdMaxRandomIops+qdMaxSeqBw))
 	queueDepth = qdTotal
@@ -75,6 +75,17 @@ func Test_getOptimalDeviceSettings(t *testing.T) {
 			wantErr:        false,
 			node:           nodeInfoNoCapabilityVM,
 		},
+		{
+			name:           "Should return scheduler none for nvme profile",
+			perfProfile:    "nvme",
+			accountType:    "Premium_LRS",
+			DiskSizeGibStr: "512",
+			diskIopsStr:    "100",
+			diskBwMbpsStr:  "100",
+			wantScheduler:  "none",
+			wantErr:        false,
+			node:           nodeInfo,
+		},
 		{
 			name:           "Should return error if matching disk sku is not found",
 			perfProfile:    "basic",